@@ -2,10 +2,15 @@ package notify
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
 )
 
 // Client for accessing GOV.UK Notify.
@@ -14,8 +19,13 @@ import (
 //  - created an account with GOV.UK Notify
 //  - found your Service ID and generated an API Key.
 //  - created at least one template and know its ID.
+//
+// A Client is safe for concurrent use by multiple goroutines. Its
+// Configuration is copied by New and cannot be changed afterwards.
 type Client struct {
-	Configuration Configuration
+	configuration Configuration
+
+	limiter *limiter
 }
 
 /**
@@ -23,12 +33,12 @@ type Client struct {
  **/
 
 func (c *Client) buildHeaders(r *http.Request) error {
-	token, err := c.Configuration.Authenticate(c.Configuration.APIKey)
+	token, err := c.configuration.TokenSource.Token()
 	if err != nil {
 		return err
 	}
 
-	r.Header.Set("Authorization", fmt.Sprintf("Bearer %s", *token))
+	r.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
 	r.Header.Set("Accept", "application/json")
 	r.Header.Set("Content-type", "application/json")
 	r.Header.Set("User-agent", fmt.Sprintf("NOTIFY-API-GO-CLIENT/%s", Version))
@@ -43,18 +53,26 @@ func (c *Client) handleInvalidResponse(res *http.Response) error {
 			StatusCode: res.StatusCode,
 		}
 
-		err := jsonResponse(res.Body, &e.Errors)
-		if err != nil {
-			return err
+		// The body is kept as it is when it is not the JSON error envelope of
+		// the API, such as the HTML page of a load balancer.
+		b, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err == nil {
+			e.Body = string(b)
+
+			envelope := errorEnvelope{}
+			if json.Unmarshal(b, &envelope) == nil {
+				e.Errors = envelope.Errors
+			}
 		}
 
-		return &e
+		return newTypedError(&e)
 	}
 
 	return nil
 }
 
-func (c *Client) httpCall(method, url string, payload *[]byte) (*http.Response, error) {
+func (c *Client) httpCall(ctx context.Context, method, url string, payload *[]byte) (*http.Response, error) {
 	var body []byte
 	if payload != nil {
 		body = *payload
@@ -64,34 +82,58 @@ func (c *Client) httpCall(method, url string, payload *[]byte) (*http.Response,
 	if err != nil {
 		return nil, err
 	}
+	req = req.WithContext(ctx)
+
+	// Wait for the limiter before signing the token, so it is not stale.
+	if c.limiter != nil {
+		err = c.limiter.wait(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	err = c.buildHeaders(req)
 	if err != nil {
 		return nil, err
 	}
 
-	return c.Configuration.HTTPClient.Do(req)
+	return c.configuration.HTTPClient.Do(req)
 }
 
-func (c *Client) httpGet(path string, query *Filters) (*http.Response, error) {
-	newURL := fmt.Sprintf("%s%s", c.Configuration.BaseURL.String(), path)
+func (c *Client) httpGet(ctx context.Context, path string, query *Filters) (*http.Response, error) {
+	// Pagination links may be absolute URLs. Only their path and query are
+	// kept, so the token is never sent anywhere but the BaseURL.
+	link, err := url.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	if link.IsAbs() {
+		path = link.RequestURI()
+	}
+
+	newURL := fmt.Sprintf("%s%s", c.configuration.BaseURL.String(), path)
 	u, err := url.Parse(newURL)
 	if err != nil {
 		return nil, err
 	}
 
 	if query != nil {
-		q := query.ToURLValues()
+		q, err := query.ToURLValues()
+		if err != nil {
+			return nil, err
+		}
 		if len(q) > 0 {
 			u.RawQuery = q.Encode()
 		}
 	}
 
-	return c.httpCall("GET", u.String(), nil)
+	return c.withRetry(ctx, nil, func() (*http.Response, error) {
+		return c.httpCall(ctx, "GET", u.String(), nil)
+	})
 }
 
-func (c *Client) httpPost(path string, payload *Payload) (*http.Response, error) {
-	newURL := fmt.Sprintf("%s%s", c.Configuration.BaseURL.String(), path)
+func (c *Client) httpPost(ctx context.Context, path string, payload interface{}) (*http.Response, error) {
+	newURL := fmt.Sprintf("%s%s", c.configuration.BaseURL.String(), path)
 	u, err := url.Parse(newURL)
 	if err != nil {
 		return nil, err
@@ -102,20 +144,97 @@ func (c *Client) httpPost(path string, payload *Payload) (*http.Response, error)
 		return nil, err
 	}
 
-	return c.httpCall("POST", u.String(), &body)
+	return c.withRetry(ctx, sendGuardFor(payload), func() (*http.Response, error) {
+		return c.httpCall(ctx, "POST", u.String(), &body)
+	})
+}
+
+func letterPDFError(err error) error {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+
+	for _, e := range apiErr.Errors {
+		switch {
+		case e.Error == "PDFNotReadyError":
+			return &PDFNotReadyError{apiErr}
+		case strings.Contains(e.Message, "validation-failed"):
+			return &LetterValidationError{apiErr}
+		}
+	}
+
+	return err
+}
+
+func (c *Client) getTemplate(ctx context.Context, path string) (*TemplateDetail, error) {
+	template := TemplateDetail{}
+
+	res, err := c.httpGet(ctx, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.handleInvalidResponse(res)
+	if err != nil {
+		return nil, err
+	}
+
+	err = jsonResponse(res.Body, &template)
+	if err != nil {
+		return nil, err
+	}
+
+	return &template, nil
 }
 
 /**
  * Internal exported
  **/
 
+// Configuration returns a copy of the Configuration the Client was created
+// with. Changing the copy has no effect on the Client.
+func (c *Client) Configuration() Configuration {
+	return c.configuration.clone()
+}
+
+// GetLetterPDF will fire a request that returns the PDF of the letter with the
+// passed notification ID. The caller is responsible for closing the returned
+// reader. A *PDFNotReadyError or *LetterValidationError is returned when the
+// PDF is not available.
+func (c *Client) GetLetterPDF(notificationID string) (io.ReadCloser, error) {
+	return c.GetLetterPDFContext(context.Background(), notificationID)
+}
+
+// GetLetterPDFContext is like GetLetterPDF, but uses ctx for the request.
+func (c *Client) GetLetterPDFContext(ctx context.Context, notificationID string) (io.ReadCloser, error) {
+	path := fmt.Sprintf(PathNotificationLetterPDF, notificationID)
+
+	res, err := c.httpGet(ctx, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.handleInvalidResponse(res)
+	if err != nil {
+		return nil, letterPDFError(err)
+	}
+
+	return res.Body, nil
+}
+
 // GetNotification will fire a request that returns details about the passed
 // notification ID.
 func (c *Client) GetNotification(id string) (*Notification, error) {
+	return c.GetNotificationContext(context.Background(), id)
+}
+
+// GetNotificationContext is like GetNotification, but uses ctx for the request.
+func (c *Client) GetNotificationContext(ctx context.Context, id string) (*Notification, error) {
 	path := fmt.Sprintf(PathNotificationLookup, id)
 	notification := Notification{}
 
-	res, err := c.httpGet(path, nil)
+	res, err := c.httpGet(ctx, path, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -133,13 +252,42 @@ func (c *Client) GetNotification(id string) (*Notification, error) {
 	return &notification, nil
 }
 
+// GetTemplate will fire a request that returns the latest version of the
+// template with the passed ID.
+func (c *Client) GetTemplate(id string) (*TemplateDetail, error) {
+	return c.GetTemplateContext(context.Background(), id)
+}
+
+// GetTemplateContext is like GetTemplate, but uses ctx for the request.
+func (c *Client) GetTemplateContext(ctx context.Context, id string) (*TemplateDetail, error) {
+	return c.getTemplate(ctx, fmt.Sprintf(PathTemplateLookup, id))
+}
+
+// GetTemplateVersion will fire a request that returns the given version of the
+// template with the passed ID.
+func (c *Client) GetTemplateVersion(id string, version int64) (*TemplateDetail, error) {
+	return c.GetTemplateVersionContext(context.Background(), id, version)
+}
+
+// GetTemplateVersionContext is like GetTemplateVersion, but uses ctx for the
+// request.
+func (c *Client) GetTemplateVersionContext(ctx context.Context, id string, version int64) (*TemplateDetail, error) {
+	return c.getTemplate(ctx, fmt.Sprintf(PathTemplateVersionLookup, id, version))
+}
+
 // ListNotifications will fire a request that returns a list of all
 // notifications for the current Service ID.
 func (c *Client) ListNotifications(filters Filters) (*NotificationList, error) {
+	return c.ListNotificationsContext(context.Background(), filters)
+}
+
+// ListNotificationsContext is like ListNotifications, but uses ctx for the
+// request.
+func (c *Client) ListNotificationsContext(ctx context.Context, filters Filters) (*NotificationList, error) {
 	path := PathNotificationList
 	notificationList := NotificationList{Client: c}
 
-	res, err := c.httpGet(path, &filters)
+	res, err := c.httpGet(ctx, path, &filters)
 	if err != nil {
 		return nil, err
 	}
@@ -157,8 +305,122 @@ func (c *Client) ListNotifications(filters Filters) (*NotificationList, error) {
 	return &notificationList, nil
 }
 
-// SendEmail will fire a request to Send an Email message.
-func (c *Client) SendEmail(emailAddress, templateID string, personalisation templateData, reference string) (*NotificationEntry, error) {
+// IterateNotifications returns an iterator walking through every page of the
+// notifications for the current Service ID matching the filters, starting
+// from the most recent one. The requests are made with the passed context.
+func (c *Client) IterateNotifications(ctx context.Context, filters Filters) *NotificationIterator {
+	return &NotificationIterator{
+		ctx:     ctx,
+		client:  c,
+		filters: filters,
+		index:   -1,
+	}
+}
+
+// ListReceivedTextMessages will fire a request that returns a list of the text
+// messages received by the current Service ID. If olderThan is set to a
+// received text message ID, only the messages received before it are returned.
+func (c *Client) ListReceivedTextMessages(olderThan string) (*ReceivedTextMessageList, error) {
+	return c.ListReceivedTextMessagesContext(context.Background(), olderThan)
+}
+
+// ListReceivedTextMessagesContext is like ListReceivedTextMessages, but uses
+// ctx for the request.
+func (c *Client) ListReceivedTextMessagesContext(ctx context.Context, olderThan string) (*ReceivedTextMessageList, error) {
+	path := PathReceivedTextMessageList
+	receivedTextMessageList := ReceivedTextMessageList{Client: c}
+
+	res, err := c.httpGet(ctx, path, &Filters{OlderThan: olderThan})
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.handleInvalidResponse(res)
+	if err != nil {
+		return nil, err
+	}
+
+	err = jsonResponse(res.Body, &receivedTextMessageList)
+	if err != nil {
+		return nil, err
+	}
+
+	return &receivedTextMessageList, nil
+}
+
+// ListTemplates will fire a request that returns the latest version of all
+// templates for the current Service ID, of the given type or of every type when
+// templateType is empty.
+func (c *Client) ListTemplates(templateType TemplateType) (*TemplateList, error) {
+	return c.ListTemplatesContext(context.Background(), templateType)
+}
+
+// ListTemplatesContext is like ListTemplates, but uses ctx for the request.
+func (c *Client) ListTemplatesContext(ctx context.Context, templateType TemplateType) (*TemplateList, error) {
+	path := PathTemplateList
+	templateList := TemplateList{}
+
+	if templateType != "" {
+		path = fmt.Sprintf("%s?%s", path, url.Values{"type": {string(templateType)}}.Encode())
+	}
+
+	res, err := c.httpGet(ctx, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.handleInvalidResponse(res)
+	if err != nil {
+		return nil, err
+	}
+
+	err = jsonResponse(res.Body, &templateList)
+	if err != nil {
+		return nil, err
+	}
+
+	return &templateList, nil
+}
+
+// PreviewTemplate will fire a request that renders the latest version of the
+// template with the passed ID using the given personalisation, without sending
+// anything.
+func (c *Client) PreviewTemplate(templateID string, personalisation templateData) (*TemplatePreview, error) {
+	return c.PreviewTemplateContext(context.Background(), templateID, personalisation)
+}
+
+// PreviewTemplateContext is like PreviewTemplate, but uses ctx for the request.
+func (c *Client) PreviewTemplateContext(ctx context.Context, templateID string, personalisation templateData) (*TemplatePreview, error) {
+	path := fmt.Sprintf(PathTemplatePreview, templateID)
+	payload := templatePreviewPayload{Personalisation: personalisation}
+	preview := TemplatePreview{}
+
+	res, err := c.httpPost(ctx, path, &payload)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.handleInvalidResponse(res)
+	if err != nil {
+		return nil, err
+	}
+
+	err = jsonResponse(res.Body, &preview)
+	if err != nil {
+		return nil, err
+	}
+
+	return &preview, nil
+}
+
+// SendEmail will fire a request to Send an Email message. The options may be
+// used to set the optional fields of the request, such as WithEmailReplyToID.
+func (c *Client) SendEmail(emailAddress, templateID string, personalisation templateData, reference string, options ...SendOption) (*EmailEntry, error) {
+	return c.SendEmailContext(context.Background(), emailAddress, templateID, personalisation, reference, options...)
+}
+
+// SendEmailContext is like SendEmail, but uses ctx for the request.
+func (c *Client) SendEmailContext(ctx context.Context, emailAddress, templateID string, personalisation templateData, reference string, options ...SendOption) (*EmailEntry, error) {
 	payload := NewPayload(
 		"email",
 		emailAddress,
@@ -166,9 +428,14 @@ func (c *Client) SendEmail(emailAddress, templateID string, personalisation temp
 		personalisation,
 		reference,
 	)
-	apiResponse := NotificationEntry{}
+	apiResponse := EmailEntry{}
+
+	err := payload.apply(options)
+	if err != nil {
+		return nil, err
+	}
 
-	res, err := c.httpPost(PathNotificationSendEmail, payload)
+	res, err := c.httpPost(ctx, PathNotificationSendEmail, payload)
 	if err != nil {
 		return nil, err
 	}
@@ -186,19 +453,24 @@ func (c *Client) SendEmail(emailAddress, templateID string, personalisation temp
 	return &apiResponse, nil
 }
 
-// SendLetter will fire a request to Send a Letter.
-// TODO Establish what this actually is and prepare for usage.
-func (c *Client) SendLetter(letter, templateID string, personalisation templateData, reference string) (*NotificationEntry, error) {
-	payload := NewPayload(
-		"letter",
-		letter,
+// SendLetter will fire a request to Send a Letter based on a template. The
+// address is merged into the personalisation and the postage is optional.
+func (c *Client) SendLetter(address LetterAddress, templateID string, personalisation templateData, reference string, postage Postage) (*LetterEntry, error) {
+	return c.SendLetterContext(context.Background(), address, templateID, personalisation, reference, postage)
+}
+
+// SendLetterContext is like SendLetter, but uses ctx for the request.
+func (c *Client) SendLetterContext(ctx context.Context, address LetterAddress, templateID string, personalisation templateData, reference string, postage Postage) (*LetterEntry, error) {
+	payload := NewLetterPayload(
+		address,
 		templateID,
 		personalisation,
 		reference,
+		postage,
 	)
-	apiResponse := NotificationEntry{}
+	apiResponse := LetterEntry{}
 
-	res, err := c.httpPost(PathNotificationSendLetter, payload)
+	res, err := c.httpPost(ctx, PathNotificationSendLetter, payload)
 	if err != nil {
 		return nil, err
 	}
@@ -216,8 +488,47 @@ func (c *Client) SendLetter(letter, templateID string, personalisation templateD
 	return &apiResponse, nil
 }
 
-// SendSms will fire a request to Send a SMS message.
-func (c *Client) SendSms(phoneNumber, templateID string, personalisation templateData, reference string) (*NotificationEntry, error) {
+// SendPrecompiledLetter will fire a request to Send a Letter from the PDF read
+// from pdf. The postage is optional and defaults to second class when empty.
+func (c *Client) SendPrecompiledLetter(reference string, pdf io.Reader, postage Postage) (*PrecompiledLetterEntry, error) {
+	return c.SendPrecompiledLetterContext(context.Background(), reference, pdf, postage)
+}
+
+// SendPrecompiledLetterContext is like SendPrecompiledLetter, but uses ctx for
+// the request.
+func (c *Client) SendPrecompiledLetterContext(ctx context.Context, reference string, pdf io.Reader, postage Postage) (*PrecompiledLetterEntry, error) {
+	payload, err := newPrecompiledLetterPayload(reference, pdf, postage)
+	if err != nil {
+		return nil, err
+	}
+	apiResponse := PrecompiledLetterEntry{}
+
+	res, err := c.httpPost(ctx, PathNotificationSendLetter, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.handleInvalidResponse(res)
+	if err != nil {
+		return nil, err
+	}
+
+	err = jsonResponse(res.Body, &apiResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return &apiResponse, nil
+}
+
+// SendSms will fire a request to Send a SMS message. The options may be used
+// to set the optional fields of the request, such as WithSmsSenderID.
+func (c *Client) SendSms(phoneNumber, templateID string, personalisation templateData, reference string, options ...SendOption) (*SmsEntry, error) {
+	return c.SendSmsContext(context.Background(), phoneNumber, templateID, personalisation, reference, options...)
+}
+
+// SendSmsContext is like SendSms, but uses ctx for the request.
+func (c *Client) SendSmsContext(ctx context.Context, phoneNumber, templateID string, personalisation templateData, reference string, options ...SendOption) (*SmsEntry, error) {
 	payload := NewPayload(
 		"sms",
 		phoneNumber,
@@ -225,9 +536,14 @@ func (c *Client) SendSms(phoneNumber, templateID string, personalisation templat
 		personalisation,
 		reference,
 	)
-	apiResponse := NotificationEntry{}
+	apiResponse := SmsEntry{}
 
-	res, err := c.httpPost(PathNotificationSendSms, payload)
+	err := payload.apply(options)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.httpPost(ctx, PathNotificationSendSms, payload)
 	if err != nil {
 		return nil, err
 	}
@@ -251,6 +567,9 @@ func (c *Client) SendSms(phoneNumber, templateID string, personalisation templat
 
 // New instance of a client will be generated for the use
 func New(configuration Configuration) (*Client, error) {
+	// Make sure later changes by the caller are not seen by the Client.
+	configuration = configuration.clone()
+
 	// Make sure the HTTP client is always set up.
 	if configuration.HTTPClient == nil {
 		configuration.HTTPClient = &http.Client{}
@@ -266,8 +585,22 @@ func New(configuration Configuration) (*Client, error) {
 		configuration.BaseURL = url
 	}
 
+	// Make sure a new token is signed for every request.
+	if configuration.TokenSource == nil {
+		configuration.TokenSource = NewTokenSource(configuration.APIKey, StandardClaims(configuration.ServiceID))
+	}
+
 	c := Client{
-		Configuration: configuration,
+		configuration: configuration,
+	}
+
+	if configuration.RateLimit != nil {
+		l, err := newLimiter(*configuration.RateLimit)
+		if err != nil {
+			return nil, err
+		}
+
+		c.limiter = l
 	}
 
 	return &c, nil
@@ -1,9 +1,16 @@
 package notify
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"sync"
+	"time"
 
 	httpmock "gopkg.in/jarcoal/httpmock.v1"
 
@@ -52,7 +59,7 @@ var _ = Describe("Client", func() {
 
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(c).NotTo(BeNil())
-			Expect(c.Configuration.BaseURL.String()).To(Equal(BaseURLProduction))
+			Expect(c.Configuration().BaseURL.String()).To(Equal(BaseURLProduction))
 		})
 
 		It("should be able to buildHeaders()", func() {
@@ -70,14 +77,14 @@ var _ = Describe("Client", func() {
 			httpmock.RegisterResponder("GET", "https://example.com",
 				httpmock.NewStringResponder(http.StatusOK, ``))
 
-			res, err := client.httpCall("GET", "https://example.com", nil)
+			res, err := client.httpCall(context.Background(), "GET", "https://example.com", nil)
 
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(res.StatusCode).To(Equal(http.StatusOK))
 		})
 
 		It("should be fail the httpCall()", func() {
-			res, err := client.httpCall("GET", "%gh&%ij", nil)
+			res, err := client.httpCall(context.Background(), "GET", "%gh&%ij", nil)
 
 			Expect(err).Should(HaveOccurred())
 			Expect(res).To(BeNil())
@@ -87,17 +94,44 @@ var _ = Describe("Client", func() {
 			httpmock.RegisterResponder("GET", "https://example.com",
 				httpmock.NewStringResponder(http.StatusUnauthorized, `[{"":"","":""}]`))
 
-			res, _ := client.httpCall("GET", "https://example.com", nil)
+			res, _ := client.httpCall(context.Background(), "GET", "https://example.com", nil)
 			err := client.handleInvalidResponse(res)
 
 			Expect(err).Should(HaveOccurred())
 		})
 
+		It("should decode the error envelope in handleInvalidResponse()", func() {
+			httpmock.RegisterResponder("GET", "https://example.com",
+				httpmock.NewStringResponder(http.StatusBadRequest, `{"status_code":400,"errors":[{"error":"ValidationError","message":"id is not a valid UUID"}]}`))
+
+			res, _ := client.httpCall(context.Background(), "GET", "https://example.com", nil)
+			err := client.handleInvalidResponse(res)
+
+			var apiErr *APIError
+			Expect(errors.As(err, &apiErr)).To(BeTrue())
+			Expect(apiErr.StatusCode).To(Equal(http.StatusBadRequest))
+			Expect(apiErr.Errors).To(Equal([]Error{{Error: "ValidationError", Message: "id is not a valid UUID"}}))
+		})
+
+		It("should keep the body of a non-JSON response in handleInvalidResponse()", func() {
+			httpmock.RegisterResponder("GET", "https://example.com",
+				httpmock.NewStringResponder(http.StatusBadGateway, `<html><body>502 Bad Gateway</body></html>`))
+
+			res, _ := client.httpCall(context.Background(), "GET", "https://example.com", nil)
+			err := client.handleInvalidResponse(res)
+
+			var apiErr *APIError
+			Expect(errors.As(err, &apiErr)).To(BeTrue())
+			Expect(apiErr.StatusCode).To(Equal(http.StatusBadGateway))
+			Expect(apiErr.Errors).To(BeEmpty())
+			Expect(apiErr.Body).To(ContainSubstring("502 Bad Gateway"))
+		})
+
 		It("should be able to httpGet()", func() {
 			httpmock.RegisterResponder("GET", "https://example.com/test",
 				httpmock.NewStringResponder(http.StatusOK, ``))
 
-			res, err := client.httpGet("/test", nil)
+			res, err := client.httpGet(context.Background(), "/test", nil)
 
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(res.StatusCode).To(Equal(http.StatusOK))
@@ -107,7 +141,7 @@ var _ = Describe("Client", func() {
 			httpmock.RegisterResponder("POST", "https://example.com/test",
 				httpmock.NewStringResponder(http.StatusAccepted, ``))
 
-			res, err := client.httpPost("/test", nil)
+			res, err := client.httpPost(context.Background(), "/test", nil)
 
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(res.StatusCode).To(Equal(http.StatusAccepted))
@@ -125,7 +159,7 @@ var _ = Describe("Client", func() {
 
 		It("should fallout if the GetNotification() fails with not found", func() {
 			httpmock.RegisterResponder("GET", "https://example.com/v2/notifications/n0t1-1234567890",
-				httpmock.NewStringResponder(http.StatusNotFound, `[{"error": "NoResultFound","message": "No result found"}]`))
+				httpmock.NewStringResponder(http.StatusNotFound, `{"status_code":404,"errors":[{"error": "NoResultFound","message": "No result found"}]}`))
 
 			notification, err := client.GetNotification("n0t1-1234567890")
 
@@ -137,7 +171,7 @@ var _ = Describe("Client", func() {
 			httpmock.RegisterResponder("GET", "https://example.com/v2/notifications",
 				httpmock.NewStringResponder(http.StatusOK, `{"notifications":[{"id":"n0t1-1234567890"}, {"id":"n0t1-0123456789"}],"links":{"current":"/v2/notifications?status=delivered","next":"/v2/notifications?older_than=n0t1-0123456789&status=delivered"}}`))
 
-			list, err := client.ListNotifications(Filters{Status: "delivered"})
+			list, err := client.ListNotifications(Filters{Status: []Status{StatusDelivered}})
 
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(list.Notifications[0].ID).To(Equal("n0t1-1234567890"))
@@ -157,12 +191,19 @@ var _ = Describe("Client", func() {
 
 		It("should allow to SendLetter()", func() {
 			httpmock.RegisterResponder("POST", "https://example.com/v2/notifications/letter",
-				httpmock.NewStringResponder(http.StatusCreated, `{"id":"df10a23e-2c6d-4ea5-87fb-82e520cbf93a"}`))
+				httpmock.NewStringResponder(http.StatusCreated, `{"id":"df10a23e-2c6d-4ea5-87fb-82e520cbf93a","content":{"body":"Dear Betty","subject":"Your appointment"}}`))
+
+			address := LetterAddress{
+				AddressLine1: "The Occupier",
+				AddressLine2: "123 High Street",
+				AddressLine3: "SW14 6BF",
+			}
 
-			res, err := client.SendLetter("xxx", "123456qwerty", templateData{}, "")
+			res, err := client.SendLetter(address, "123456qwerty", templateData{}, "", "")
 
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(res.ID).To(Equal("df10a23e-2c6d-4ea5-87fb-82e520cbf93a"))
+			Expect(res.Content.Subject).To(Equal("Your appointment"))
 		})
 
 		It("should allow to SendSms()", func() {
@@ -174,5 +215,187 @@ var _ = Describe("Client", func() {
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(res.ID).To(Equal("df10a23e-2c6d-4ea5-87fb-82e520cbf93a"))
 		})
+
+		It("should send the options with SendEmail()", func() {
+			var body map[string]interface{}
+
+			httpmock.RegisterResponder("POST", "https://example.com/v2/notifications/email",
+				func(req *http.Request) (*http.Response, error) {
+					json.NewDecoder(req.Body).Decode(&body)
+
+					return httpmock.NewStringResponse(http.StatusCreated, `{"id":"df10a23e-2c6d-4ea5-87fb-82e520cbf93a"}`), nil
+				})
+
+			_, err := client.SendEmail("test@example.com", "123456qwerty", templateData{}, "", WithEmailReplyToID("8e222534-7f05-4972-86e3-17c5d9f894e2"))
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(body["email_reply_to_id"]).To(Equal("8e222534-7f05-4972-86e3-17c5d9f894e2"))
+			_, ok := body["phone_number"]
+			Expect(ok).To(BeFalse())
+		})
+
+		It("should fail to SendSms() with an email option", func() {
+			res, err := client.SendSms("00000000000", "123456qwerty", templateData{}, "", WithEmailReplyToID("8e222534-7f05-4972-86e3-17c5d9f894e2"))
+
+			Expect(err).Should(HaveOccurred())
+			Expect(res).To(BeNil())
+		})
+
+		It("should pass the context to the request with GetNotificationContext()", func() {
+			var ctx context.Context
+
+			httpmock.RegisterResponder("GET", "https://example.com/v2/notifications/n0t1-1234567890",
+				func(req *http.Request) (*http.Response, error) {
+					ctx = req.Context()
+
+					return httpmock.NewStringResponse(http.StatusOK, `{"id":"n0t1-1234567890"}`), nil
+				})
+
+			type key struct{}
+			parent := context.WithValue(context.Background(), key{}, "value")
+
+			_, err := client.GetNotificationContext(parent, "n0t1-1234567890")
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(ctx.Value(key{})).To(Equal("value"))
+		})
+
+	})
+
+	Context("with a context", func() {
+		var (
+			client *Client
+			server *httptest.Server
+		)
+
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ioutil.ReadAll(r.Body)
+				<-r.Context().Done()
+			}))
+
+			u, _ := url.Parse(server.URL)
+
+			client, _ = New(Configuration{
+				APIKey:     []byte("secret"),
+				BaseURL:    u,
+				HTTPClient: &http.Client{Transport: &http.Transport{}},
+				ServiceID:  "test",
+			})
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should fail to SendEmailContext() when the deadline is exceeded", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+
+			res, err := client.SendEmailContext(ctx, "test@example.com", "123456qwerty", templateData{}, "")
+
+			Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+			Expect(res).To(BeNil())
+		})
+	})
+
+	Context("sharing a Client across goroutines", func() {
+		var (
+			client *Client
+			server *httptest.Server
+		)
+
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ioutil.ReadAll(r.Body)
+
+				w.Header().Set("Content-Type", "application/json")
+
+				switch r.URL.Path {
+				case PathNotificationList:
+					w.Write([]byte(`{"notifications":[{"id":"n0t1-1234567890"}],"links":{}}`))
+				default:
+					w.WriteHeader(http.StatusCreated)
+					w.Write([]byte(`{"id":"df10a23e-2c6d-4ea5-87fb-82e520cbf93a"}`))
+				}
+			}))
+
+			u, _ := url.Parse(server.URL)
+
+			client, _ = New(Configuration{
+				APIKey:      []byte("secret"),
+				BaseURL:     u,
+				HTTPClient:  &http.Client{Transport: &http.Transport{}},
+				RateLimit:   &RateLimit{Requests: 10000, Interval: time.Second},
+				RetryPolicy: &DefaultRetryPolicy,
+				ServiceID:   "test",
+			})
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should send and list from many goroutines at once", func() {
+			const workers = 20
+
+			var wg sync.WaitGroup
+			errs := make(chan error, workers*4)
+
+			for i := 0; i < workers; i++ {
+				wg.Add(1)
+
+				go func(i int) {
+					defer wg.Done()
+
+					reference := fmt.Sprintf("ref-%d", i)
+
+					_, err := client.SendEmail("test@example.com", "123456qwerty", templateData{"n": i}, reference)
+					errs <- err
+
+					_, err = client.SendSms("+447900900123", "123456qwerty", templateData{"n": i}, reference)
+					errs <- err
+
+					_, err = client.SendLetter(LetterAddress{AddressLine1: "A", AddressLine2: "B", AddressLine3: "SW1A 1AA"}, "123456qwerty", templateData{}, reference, PostageSecond)
+					errs <- err
+
+					_, err = client.ListNotifications(Filters{Reference: reference})
+					errs <- err
+				}(i)
+			}
+
+			wg.Wait()
+			close(errs)
+
+			for err := range errs {
+				Expect(err).ShouldNot(HaveOccurred())
+			}
+		})
+	})
+
+	Context("changing the Configuration after New()", func() {
+		It("should not change the Client", func() {
+			u, _ := url.Parse("https://example.com")
+			config := Configuration{
+				APIKey:      []byte("secret"),
+				BaseURL:     u,
+				RetryPolicy: &RetryPolicy{MaxAttempts: 3},
+				ServiceID:   "test",
+			}
+
+			client, err := New(config)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			config.APIKey[0] = 'S'
+			config.BaseURL.Host = "example.org"
+			config.RetryPolicy.MaxAttempts = 1
+
+			copied := client.Configuration()
+			copied.BaseURL.Host = "example.net"
+
+			Expect(string(client.Configuration().APIKey)).To(Equal("secret"))
+			Expect(client.Configuration().BaseURL.String()).To(Equal("https://example.com"))
+			Expect(client.Configuration().RetryPolicy.MaxAttempts).To(Equal(3))
+		})
 	})
 })
@@ -1,33 +1,112 @@
 package notify
 
 import (
+	"errors"
 	"net/http"
 	"net/url"
-	"time"
-
-	jwt "github.com/dgrijalva/jwt-go"
+	"regexp"
 )
 
 // Configuration of the Notifications Go Client.
+//
+// The TokenSource is optional and, when not set, New will set it up to sign a
+// new token with the APIKey for the ServiceID on every request. Requests are
+// made once, unless a RetryPolicy is set, and as fast as they come, unless a
+// RateLimit is set.
 type Configuration struct {
-	APIKey     []byte
-	BaseURL    *url.URL
-	Claims     *jwt.StandardClaims
-	HTTPClient *http.Client
-	ServiceID  string
+	APIKey      []byte
+	BaseURL     *url.URL
+	HTTPClient  *http.Client
+	RateLimit   *RateLimit
+	RetryPolicy *RetryPolicy
+	ServiceID   string
+	TokenSource TokenSource
 }
 
-// Authenticate a JWT token. JwtTokenCreator uses HMAC-SHA256 signature, by default.
-func (c *Configuration) Authenticate(secret []byte) (*string, error) {
-	if c.Claims == nil {
-		c.Claims = &jwt.StandardClaims{
-			Issuer:   c.ServiceID,
-			IssuedAt: time.Now().Unix(),
+// clone returns a copy of the Configuration that shares no mutable state with
+// the original, so that changes made by the caller after New are not seen by
+// the Client. The HTTPClient and TokenSource are shared, as they must already
+// be safe for concurrent use.
+func (c Configuration) clone() Configuration {
+	if c.APIKey != nil {
+		c.APIKey = append([]byte(nil), c.APIKey...)
+	}
+
+	if c.BaseURL != nil {
+		u := *c.BaseURL
+		if u.User != nil {
+			user := *u.User
+			u.User = &user
 		}
+		c.BaseURL = &u
+	}
+
+	if c.RateLimit != nil {
+		r := *c.RateLimit
+		c.RateLimit = &r
+	}
+
+	if c.RetryPolicy != nil {
+		r := *c.RetryPolicy
+		c.RetryPolicy = &r
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, c.Claims)
-	tokenString, err := token.SignedString(secret)
+	return c
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// uuidLength is the length of the Service ID and of the secret in an API key.
+const uuidLength = 36
+
+// NewConfiguration of the Notifications Go Client, from the API key as it is
+// issued by GOV.UK Notify. The BaseURL and HTTPClient may be set on the returned
+// Configuration before passing it to New.
+func NewConfiguration(apiKey string) (Configuration, error) {
+	serviceID, secret, err := ParseAPIKey(apiKey)
+	if err != nil {
+		return Configuration{}, err
+	}
+
+	c := Configuration{
+		APIKey:    secret,
+		ServiceID: serviceID,
+	}
+
+	return c, nil
+}
+
+// ParseAPIKey splits the API key issued by GOV.UK Notify, in the format
+// {key_name}-{service_id}-{secret}, into the Service ID and the secret.
+func ParseAPIKey(apiKey string) (serviceID string, secret []byte, err error) {
+	// The key name may itself contain dashes, so the key is split from the end.
+	// It must be at least one character long.
+	if len(apiKey) < 2*uuidLength+3 {
+		return "", nil, errors.New("config: api key is too short, expected {key_name}-{service_id}-{secret}")
+	}
+
+	s := apiKey[len(apiKey)-uuidLength:]
+	id := apiKey[len(apiKey)-2*uuidLength-1 : len(apiKey)-uuidLength-1]
+
+	if apiKey[len(apiKey)-uuidLength-1] != '-' || apiKey[len(apiKey)-2*uuidLength-2] != '-' {
+		return "", nil, errors.New("config: api key is malformed, expected {key_name}-{service_id}-{secret}")
+	}
+
+	if !uuidPattern.MatchString(id) {
+		return "", nil, errors.New("config: service id in api key is not a valid UUID")
+	}
+
+	if !uuidPattern.MatchString(s) {
+		return "", nil, errors.New("config: secret in api key is not a valid UUID")
+	}
+
+	return id, []byte(s), nil
+}
+
+// Authenticate a JWT token. JwtTokenCreator uses HMAC-SHA256 signature, by default.
+// A new token, issued at the current time, is created on every call.
+func (c *Configuration) Authenticate(secret []byte) (*string, error) {
+	tokenString, err := NewTokenSource(secret, StandardClaims(c.ServiceID)).Token()
 	if err != nil {
 		return nil, err
 	}
@@ -17,4 +17,37 @@ var _ = Describe("Config", func() {
 		Expect(err).ShouldNot(HaveOccurred())
 		Expect(*token).NotTo(BeEmpty())
 	})
+
+	It("should ParseAPIKey() into the Service ID and secret", func() {
+		serviceID, secret, err := ParseAPIKey("my-test_key-26785a09-ab16-4eb0-8407-a37497a57506-3d844edf-8d35-48ac-975b-e847b4f122b0")
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(serviceID).To(Equal("26785a09-ab16-4eb0-8407-a37497a57506"))
+		Expect(string(secret)).To(Equal("3d844edf-8d35-48ac-975b-e847b4f122b0"))
+	})
+
+	It("should fail to ParseAPIKey() malformed keys", func() {
+		keys := []string{
+			"",
+			"26785a09-ab16-4eb0-8407-a37497a57506-3d844edf-8d35-48ac-975b-e847b4f122b0",
+			"-26785a09-ab16-4eb0-8407-a37497a57506-3d844edf-8d35-48ac-975b-e847b4f122b0",
+			"key-26785a09-ab16-4eb0-8407-a37497a57506_3d844edf-8d35-48ac-975b-e847b4f122b0",
+			"key-26785a09-ab16-4eb0-8407-a37497a5750z-3d844edf-8d35-48ac-975b-e847b4f122b0",
+			"key-26785a09-ab16-4eb0-8407-a37497a57506-3d844edf-8d35-48ac-975b-e847b4f122bz",
+		}
+
+		for _, key := range keys {
+			_, _, err := ParseAPIKey(key)
+
+			Expect(err).Should(HaveOccurred(), key)
+		}
+	})
+
+	It("should create NewConfiguration() from the API key", func() {
+		config, err := NewConfiguration("key-26785a09-ab16-4eb0-8407-a37497a57506-3d844edf-8d35-48ac-975b-e847b4f122b0")
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(config.ServiceID).To(Equal("26785a09-ab16-4eb0-8407-a37497a57506"))
+		Expect(config.APIKey).To(Equal([]byte("3d844edf-8d35-48ac-975b-e847b4f122b0")))
+	})
 })
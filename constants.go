@@ -15,6 +15,10 @@ const PathNotificationList = "/v2/notifications"
 // lookup of any notifications.
 const PathNotificationLookup = "/v2/notifications/%s"
 
+// PathNotificationLetterPDF directs to the appropriate endpoint responsible for
+// retrieving the PDF of a letter.
+const PathNotificationLetterPDF = "/v2/notifications/%s/pdf"
+
 // PathNotificationSendEmail directs to the appropriate endpoint responsible for
 // sending an email message.
 const PathNotificationSendEmail = "/v2/notifications/email"
@@ -26,3 +30,23 @@ const PathNotificationSendLetter = "/v2/notifications/letter"
 // PathNotificationSendSms directs to the appropriate endpoint responsible for
 // sending a text message.
 const PathNotificationSendSms = "/v2/notifications/sms"
+
+// PathReceivedTextMessageList directs to the appropriate endpoint responsible
+// for retrieving the list of received text messages.
+const PathReceivedTextMessageList = "/v2/received-text-messages"
+
+// PathTemplateList directs to the appropriate endpoint responsible for
+// retrieving the list of templates.
+const PathTemplateList = "/v2/templates"
+
+// PathTemplateLookup directs to the appropriate endpoint responsible for
+// lookup of the latest version of a template.
+const PathTemplateLookup = "/v2/template/%s"
+
+// PathTemplatePreview directs to the appropriate endpoint responsible for
+// rendering a template with personalisation.
+const PathTemplatePreview = "/v2/template/%s/preview"
+
+// PathTemplateVersionLookup directs to the appropriate endpoint responsible
+// for lookup of a specific version of a template.
+const PathTemplateVersionLookup = "/v2/template/%s/version/%d"
@@ -1,20 +1,177 @@
 package notify
 
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
 // APIError is a custom error-type struct adjusted for the API usage.
+//
+// The errors returned by the client for a failed request wrap an APIError in
+// one of the more specific types below, such as RateLimitError, which can be
+// recognised with errors.As. An APIError can also be matched on its status
+// code with errors.Is, for example errors.Is(err, &APIError{StatusCode: 404}).
 type APIError struct {
 	Message    string
 	StatusCode int
 	Errors     []Error
+	// Body of the response, kept to help with debugging responses that are
+	// not the JSON errors of the API.
+	Body string
 }
 
 // Error method is here to return a top level error message as well as define
-// our new error type.
+// our new error type. It includes the status code and the errors returned by
+// the API.
 func (e *APIError) Error() string {
-	return e.Message
+	if len(e.Errors) == 0 {
+		return fmt.Sprintf("%s (status code %d)", e.Message, e.StatusCode)
+	}
+
+	errs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, fmt.Sprintf("%s: %s", err.Error, err.Message))
+	}
+
+	return fmt.Sprintf("%s (status code %d): %s", e.Message, e.StatusCode, strings.Join(errs, "; "))
+}
+
+// Is reports whether the target is an APIError with the same status code, or
+// with no status code at all.
+func (e *APIError) Is(target error) bool {
+	t, ok := target.(*APIError)
+	if !ok {
+		return false
+	}
+
+	return t.StatusCode == 0 || t.StatusCode == e.StatusCode
+}
+
+// has reports whether the API returned an error of the given name.
+func (e *APIError) has(name string) bool {
+	for _, err := range e.Errors {
+		if err.Error == name {
+			return true
+		}
+	}
+
+	return false
 }
 
 // Error may be returned by the API.
 type Error struct {
-	Error   string
-	Message string
+	Error   string `json:"error"`
+	Message string `json:"message"`
+}
+
+// errorEnvelope is the body of the responses of the API for failed requests.
+type errorEnvelope struct {
+	StatusCode int     `json:"status_code"`
+	Errors     []Error `json:"errors"`
 }
+
+// RateLimitError is returned when the rate limit of the API key is exceeded.
+// The request can be tried again after a short while.
+type RateLimitError struct {
+	*APIError
+}
+
+// Unwrap returns the APIError.
+func (e *RateLimitError) Unwrap() error { return e.APIError }
+
+// TooManyRequestsError is returned when the daily limit of messages sent by
+// the service is exceeded. The request should not be tried again until the
+// next day.
+type TooManyRequestsError struct {
+	*APIError
+}
+
+// Unwrap returns the APIError.
+func (e *TooManyRequestsError) Unwrap() error { return e.APIError }
+
+// BadRequestError is returned when the request can not be fulfilled, for
+// example when sending to a recipient not allowed by the API key.
+type BadRequestError struct {
+	*APIError
+}
+
+// Unwrap returns the APIError.
+func (e *BadRequestError) Unwrap() error { return e.APIError }
+
+// ValidationError is returned when the request is invalid, for example when an
+// ID is not a valid UUID.
+type ValidationError struct {
+	*APIError
+}
+
+// Unwrap returns the APIError.
+func (e *ValidationError) Unwrap() error { return e.APIError }
+
+// AuthError is returned when the request could not be authenticated, for
+// example when the API key has been revoked or the token has expired.
+type AuthError struct {
+	*APIError
+}
+
+// Unwrap returns the APIError.
+func (e *AuthError) Unwrap() error { return e.APIError }
+
+// NotFoundError is returned when the requested notification or template does
+// not exist.
+type NotFoundError struct {
+	*APIError
+}
+
+// Unwrap returns the APIError.
+func (e *NotFoundError) Unwrap() error { return e.APIError }
+
+// ServerError is returned when GOV.UK Notify failed to handle the request. The
+// request can be tried again.
+type ServerError struct {
+	*APIError
+}
+
+// Unwrap returns the APIError.
+func (e *ServerError) Unwrap() error { return e.APIError }
+
+// newTypedError wraps the APIError in the type matching its status code and
+// errors. The APIError itself is returned when no type matches.
+func newTypedError(e *APIError) error {
+	switch {
+	case e.StatusCode == http.StatusTooManyRequests && e.has("TooManyRequestsError"):
+		return &TooManyRequestsError{e}
+	case e.StatusCode == http.StatusTooManyRequests:
+		return &RateLimitError{e}
+	case e.StatusCode == http.StatusBadRequest && e.has("ValidationError"):
+		return &ValidationError{e}
+	case e.StatusCode == http.StatusBadRequest:
+		return &BadRequestError{e}
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+		return &AuthError{e}
+	case e.StatusCode == http.StatusNotFound:
+		return &NotFoundError{e}
+	case e.StatusCode >= http.StatusInternalServerError:
+		return &ServerError{e}
+	}
+
+	return e
+}
+
+// PDFNotReadyError is returned when the PDF of a letter has not been generated
+// yet. The request can be tried again later.
+type PDFNotReadyError struct {
+	*APIError
+}
+
+// Unwrap returns the APIError.
+func (e *PDFNotReadyError) Unwrap() error { return e.APIError }
+
+// LetterValidationError is returned when the PDF of a letter is not available,
+// because the letter has failed validation.
+type LetterValidationError struct {
+	*APIError
+}
+
+// Unwrap returns the APIError.
+func (e *LetterValidationError) Unwrap() error { return e.APIError }
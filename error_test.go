@@ -0,0 +1,68 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Error", func() {
+	It("should include the status code and the errors in Error()", func() {
+		e := APIError{
+			Message:    "api: encountered following errors",
+			StatusCode: http.StatusBadRequest,
+			Errors: []Error{
+				{Error: "ValidationError", Message: "id is not a valid UUID"},
+				{Error: "ValidationError", Message: "status is not one of [sending, delivered]"},
+			},
+		}
+
+		Expect(e.Error()).To(Equal("api: encountered following errors (status code 400): ValidationError: id is not a valid UUID; ValidationError: status is not one of [sending, delivered]"))
+	})
+
+	It("should include the status code in Error() without errors", func() {
+		e := APIError{Message: "api: encountered following errors", StatusCode: http.StatusBadGateway}
+
+		Expect(e.Error()).To(Equal("api: encountered following errors (status code 502)"))
+	})
+
+	DescribeTable("newTypedError()",
+		func(statusCode int, name string, target interface{}) {
+			e := &APIError{StatusCode: statusCode, Errors: []Error{{Error: name}}}
+			err := fmt.Errorf("wrapped: %w", newTypedError(e))
+
+			Expect(errors.As(err, target)).To(BeTrue())
+
+			var apiErr *APIError
+			Expect(errors.As(err, &apiErr)).To(BeTrue())
+			Expect(apiErr).To(Equal(e))
+		},
+		Entry("RateLimitError", 429, "RateLimitError", new(*RateLimitError)),
+		Entry("TooManyRequestsError", 429, "TooManyRequestsError", new(*TooManyRequestsError)),
+		Entry("ValidationError", 400, "ValidationError", new(*ValidationError)),
+		Entry("BadRequestError", 400, "BadRequestError", new(*BadRequestError)),
+		Entry("AuthError for 401", 401, "AuthError", new(*AuthError)),
+		Entry("AuthError for 403", 403, "AuthError", new(*AuthError)),
+		Entry("NotFoundError", 404, "NoResultFound", new(*NotFoundError)),
+		Entry("ServerError", 500, "Exception", new(*ServerError)),
+	)
+
+	It("should not confuse a RateLimitError with a TooManyRequestsError", func() {
+		err := newTypedError(&APIError{StatusCode: 429, Errors: []Error{{Error: "RateLimitError"}}})
+
+		var target *TooManyRequestsError
+		Expect(errors.As(err, &target)).To(BeFalse())
+	})
+
+	It("should match the status code with errors.Is()", func() {
+		err := newTypedError(&APIError{StatusCode: 404})
+
+		Expect(errors.Is(err, &APIError{StatusCode: 404})).To(BeTrue())
+		Expect(errors.Is(err, &APIError{})).To(BeTrue())
+		Expect(errors.Is(err, &APIError{StatusCode: 400})).To(BeFalse())
+	})
+})
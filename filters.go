@@ -1,25 +1,45 @@
 package notify
 
-import "net/url"
+import (
+	"fmt"
+	"net/url"
+)
 
 // Filters for the notifications to be looked at.
 type Filters struct {
-	OlderThan    string `json:"older_than"`
-	Reference    string `json:"reference"`
-	Status       string `json:"status"`
-	TemplateType string `json:"template_type"`
+	OlderThan    string       `json:"older_than"`
+	Reference    string       `json:"reference"`
+	Status       []Status     `json:"status"`
+	TemplateType TemplateType `json:"template_type"`
+	IncludeJobs  bool         `json:"include_jobs"`
 }
 
-// ToURLValues will convert the struct into the url.Values.
-func (f *Filters) ToURLValues() url.Values {
+// ToURLValues will convert the struct into the url.Values. An error is
+// returned when a status or the template type is not known to GOV.UK Notify.
+func (f *Filters) ToURLValues() (url.Values, error) {
 	m := url.Values{}
 
+	for _, s := range f.Status {
+		if !s.IsValid() {
+			return nil, fmt.Errorf("filters: %q is not a valid status", s)
+		}
+
+		m.Add("status", string(s))
+	}
+
+	if f.TemplateType != "" && !f.TemplateType.IsValid() {
+		return nil, fmt.Errorf("filters: %q is not a valid template type", f.TemplateType)
+	}
+
 	f.addIfNotEmpty(&m, "older_than", f.OlderThan)
 	f.addIfNotEmpty(&m, "reference", f.Reference)
-	f.addIfNotEmpty(&m, "status", f.Status)
-	f.addIfNotEmpty(&m, "template_type", f.TemplateType)
+	f.addIfNotEmpty(&m, "template_type", string(f.TemplateType))
+
+	if f.IncludeJobs {
+		m.Add("include_jobs", "true")
+	}
 
-	return m
+	return m, nil
 }
 
 func (f *Filters) addIfNotEmpty(m *url.Values, key, value string) {
@@ -14,8 +14,9 @@ var _ = Describe("Filters", func() {
 		f = Filters{
 			"older_than",
 			"reference",
-			"status",
-			"template_type",
+			[]Status{StatusDelivered},
+			TemplateTypeSms,
+			false,
 		}
 	})
 
@@ -31,21 +32,25 @@ var _ = Describe("Filters", func() {
 	})
 
 	It("should be handle converting ToURLValues()", func() {
-		v := f.ToURLValues()
+		v, err := f.ToURLValues()
 
+		Expect(err).ShouldNot(HaveOccurred())
 		Expect(v).NotTo(BeEmpty())
 		Expect(v["older_than"][0]).To(Equal("older_than"))
 		Expect(v["reference"][0]).To(Equal("reference"))
-		Expect(v["status"][0]).To(Equal("status"))
-		Expect(v["template_type"][0]).To(Equal("template_type"))
+		Expect(v["status"][0]).To(Equal("delivered"))
+		Expect(v["template_type"][0]).To(Equal("sms"))
+		_, okIncludeJobs := v["include_jobs"]
+		Expect(okIncludeJobs).To(BeFalse())
 	})
 
 	It("should ignore empty fields in the ToURLValues() conversion", func() {
-		f.Status = ""
+		f.Status = nil
 		f.TemplateType = ""
 
-		v := f.ToURLValues()
+		v, err := f.ToURLValues()
 
+		Expect(err).ShouldNot(HaveOccurred())
 		Expect(v).NotTo(BeEmpty())
 		Expect(v["older_than"][0]).To(Equal("older_than"))
 		Expect(v["reference"][0]).To(Equal("reference"))
@@ -54,4 +59,31 @@ var _ = Describe("Filters", func() {
 		_, okTemplateType := v["template_type"]
 		Expect(okTemplateType).To(BeFalse())
 	})
+
+	It("should convert multiple statuses and include_jobs ToURLValues()", func() {
+		f.Status = []Status{StatusDelivered, StatusFailed}
+		f.IncludeJobs = true
+
+		v, err := f.ToURLValues()
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(v["status"]).To(Equal([]string{"delivered", "failed"}))
+		Expect(v["include_jobs"]).To(Equal([]string{"true"}))
+	})
+
+	It("should fail to convert an unknown status ToURLValues()", func() {
+		f.Status = []Status{StatusDelivered, "delivred"}
+
+		_, err := f.ToURLValues()
+
+		Expect(err).Should(HaveOccurred())
+	})
+
+	It("should fail to convert an unknown template type ToURLValues()", func() {
+		f.TemplateType = "Apple"
+
+		_, err := f.ToURLValues()
+
+		Expect(err).Should(HaveOccurred())
+	})
 })
@@ -0,0 +1,110 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+)
+
+// Postage class to be used when sending a letter.
+type Postage string
+
+// Postage classes accepted by GOV.UK Notify. Letters are sent second class
+// unless a postage is set.
+const (
+	PostageFirst       Postage = "first"
+	PostageSecond      Postage = "second"
+	PostageEconomy     Postage = "economy"
+	PostageEurope      Postage = "europe"
+	PostageRestOfWorld Postage = "rest-of-world"
+)
+
+// LetterAddress of the recipient of a letter. At least the first three lines
+// must be set, and the last line set must be a postcode or a country.
+type LetterAddress struct {
+	AddressLine1 string
+	AddressLine2 string
+	AddressLine3 string
+	AddressLine4 string
+	AddressLine5 string
+	AddressLine6 string
+	AddressLine7 string
+}
+
+// merge the address lines into a copy of the personalisation, as expected by
+// the API.
+func (a LetterAddress) merge(personalisation templateData) templateData {
+	m := templateData{}
+	for k, v := range personalisation {
+		m[k] = v
+	}
+
+	lines := []string{
+		a.AddressLine1,
+		a.AddressLine2,
+		a.AddressLine3,
+		a.AddressLine4,
+		a.AddressLine5,
+		a.AddressLine6,
+		a.AddressLine7,
+	}
+	for i, line := range lines {
+		if line != "" {
+			m[fmt.Sprintf("address_line_%d", i+1)] = line
+		}
+	}
+
+	return m
+}
+
+// LetterContent is the rendered letter returned as part of LetterEntry.
+type LetterContent struct {
+	Body    string `json:"body"`
+	Subject string `json:"subject"`
+}
+
+// LetterEntry is the struct around the successful response from the API
+// collected upon the creation of a new letter based on a template.
+type LetterEntry struct {
+	NotificationEntry
+	Content LetterContent `json:"content"`
+	Postage Postage       `json:"postage"`
+}
+
+// PrecompiledLetterEntry is the struct around the successful response from the
+// API collected upon the creation of a new precompiled letter.
+type PrecompiledLetterEntry struct {
+	ID        string  `json:"id"`
+	Reference string  `json:"reference"`
+	Postage   Postage `json:"postage"`
+}
+
+type precompiledLetterPayload struct {
+	Reference string  `json:"reference"`
+	Content   string  `json:"content"`
+	Postage   Postage `json:"postage,omitempty"`
+}
+
+func newPrecompiledLetterPayload(reference string, pdf io.Reader, postage Postage) (*precompiledLetterPayload, error) {
+	var buf bytes.Buffer
+
+	encoder := base64.NewEncoder(base64.StdEncoding, &buf)
+	_, err := io.Copy(encoder, pdf)
+	if err != nil {
+		return nil, err
+	}
+
+	err = encoder.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	p := precompiledLetterPayload{
+		Reference: reference,
+		Content:   buf.String(),
+		Postage:   postage,
+	}
+
+	return &p, nil
+}
@@ -0,0 +1,163 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	httpmock "gopkg.in/jarcoal/httpmock.v1"
+)
+
+var _ = Describe("Letter", func() {
+	Context("Address", func() {
+		It("should merge() the address lines into the personalisation", func() {
+			address := LetterAddress{
+				AddressLine1: "The Occupier",
+				AddressLine2: "123 High Street",
+				AddressLine7: "SW14 6BF",
+			}
+			personalisation := templateData{"name": "Betty"}
+
+			m := address.merge(personalisation)
+
+			Expect(m).To(Equal(templateData{
+				"name":           "Betty",
+				"address_line_1": "The Occupier",
+				"address_line_2": "123 High Street",
+				"address_line_7": "SW14 6BF",
+			}))
+			Expect(personalisation).To(HaveLen(1))
+		})
+	})
+
+	Context("Precompiled", func() {
+		var (
+			client *Client
+			body   map[string]interface{}
+		)
+
+		BeforeEach(func() {
+			httpmock.Activate()
+
+			u, _ := url.Parse("https://example.com")
+
+			config := Configuration{
+				APIKey: []byte(`#5K+･ｼミew{ｦ住ｳ(跼Tﾉ(ｩ┫ﾒP.ｿﾓ燾辻G�感ﾃwb="=.!r.Oﾀﾍ奎gﾐ｣`),
+				BaseURL:   u,
+				ServiceID: "test",
+			}
+
+			client, _ = New(config)
+
+			body = nil
+			httpmock.RegisterResponder("POST", "https://example.com/v2/notifications/letter",
+				func(req *http.Request) (*http.Response, error) {
+					json.NewDecoder(req.Body).Decode(&body)
+
+					return httpmock.NewStringResponse(http.StatusCreated, `{"id":"df10a23e-2c6d-4ea5-87fb-82e520cbf93a","reference":"my-letter","postage":"first"}`), nil
+				})
+		})
+
+		AfterEach(func() {
+			httpmock.DeactivateAndReset()
+		})
+
+		It("should be able to run newPrecompiledLetterPayload()", func() {
+			p, err := newPrecompiledLetterPayload("my-letter", bytes.NewReader([]byte("%PDF-1.4")), PostageSecond)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(p.Reference).To(Equal("my-letter"))
+			Expect(p.Content).To(Equal("JVBERi0xLjQ="))
+			Expect(p.Postage).To(Equal(PostageSecond))
+		})
+
+		It("should allow to SendPrecompiledLetter()", func() {
+			res, err := client.SendPrecompiledLetter("my-letter", bytes.NewReader([]byte("%PDF-1.4")), PostageFirst)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(body["content"]).To(Equal("JVBERi0xLjQ="))
+			Expect(body["postage"]).To(Equal("first"))
+			Expect(res.ID).To(Equal("df10a23e-2c6d-4ea5-87fb-82e520cbf93a"))
+			Expect(res.Reference).To(Equal("my-letter"))
+			Expect(res.Postage).To(Equal(PostageFirst))
+		})
+
+		It("should omit the postage on SendPrecompiledLetter() when not set", func() {
+			_, err := client.SendPrecompiledLetter("my-letter", bytes.NewReader([]byte("%PDF-1.4")), "")
+
+			Expect(err).ShouldNot(HaveOccurred())
+			_, ok := body["postage"]
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Context("PDF", func() {
+		var (
+			client *Client
+		)
+
+		BeforeEach(func() {
+			httpmock.Activate()
+
+			u, _ := url.Parse("https://example.com")
+
+			config := Configuration{
+				APIKey: []byte(`#5K+･ｼミew{ｦ住ｳ(跼Tﾉ(ｩ┫ﾒP.ｿﾓ燾辻G�感ﾃwb="=.!r.Oﾀﾍ奎gﾐ｣`),
+				BaseURL:   u,
+				ServiceID: "test",
+			}
+
+			client, _ = New(config)
+		})
+
+		AfterEach(func() {
+			httpmock.DeactivateAndReset()
+		})
+
+		It("should allow to GetLetterPDF()", func() {
+			httpmock.RegisterResponder("GET", "https://example.com/v2/notifications/n0t1-1234567890/pdf",
+				httpmock.NewBytesResponder(http.StatusOK, []byte("%PDF-1.4")))
+
+			pdf, err := client.GetLetterPDF("n0t1-1234567890")
+
+			Expect(err).ShouldNot(HaveOccurred())
+			defer pdf.Close()
+			b, _ := ioutil.ReadAll(pdf)
+			Expect(string(b)).To(Equal("%PDF-1.4"))
+		})
+
+		It("should return PDFNotReadyError from GetLetterPDF() when not ready", func() {
+			httpmock.RegisterResponder("GET", "https://example.com/v2/notifications/n0t1-1234567890/pdf",
+				httpmock.NewStringResponder(http.StatusBadRequest, `{"status_code":400,"errors":[{"error":"PDFNotReadyError","message":"PDF not available yet, try again later"}]}`))
+
+			pdf, err := client.GetLetterPDF("n0t1-1234567890")
+
+			Expect(pdf).To(BeNil())
+			Expect(err).To(BeAssignableToTypeOf(&PDFNotReadyError{}))
+		})
+
+		It("should return LetterValidationError from GetLetterPDF() when validation failed", func() {
+			httpmock.RegisterResponder("GET", "https://example.com/v2/notifications/n0t1-1234567890/pdf",
+				httpmock.NewStringResponder(http.StatusBadRequest, `{"status_code":400,"errors":[{"error":"BadRequestError","message":"PDF not available for letters in status validation-failed"}]}`))
+
+			pdf, err := client.GetLetterPDF("n0t1-1234567890")
+
+			Expect(pdf).To(BeNil())
+			Expect(err).To(BeAssignableToTypeOf(&LetterValidationError{}))
+		})
+
+		It("should return the APIError from GetLetterPDF() otherwise", func() {
+			httpmock.RegisterResponder("GET", "https://example.com/v2/notifications/n0t1-1234567890/pdf",
+				httpmock.NewStringResponder(http.StatusNotFound, `{"status_code":404,"errors":[{"error":"NoResultFound","message":"No result found"}]}`))
+
+			pdf, err := client.GetLetterPDF("n0t1-1234567890")
+
+			Expect(pdf).To(BeNil())
+			Expect(err).To(BeAssignableToTypeOf(&NotFoundError{}))
+		})
+	})
+})
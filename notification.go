@@ -1,18 +1,19 @@
 package notify
 
 import (
+	"context"
 	"errors"
 	"time"
 )
 
 // Template may be returned as part of Notification response.
 type Template struct {
-	ID      int64  `json:"id"`
+	ID      string `json:"id"`
 	URI     string `json:"uri"`
 	Version int64  `json:"version"`
 }
 
-type templateData map[string]string
+type templateData map[string]interface{}
 
 // Pagination of the list that's returned as part of the JSON response.
 type Pagination struct {
@@ -23,34 +24,81 @@ type Pagination struct {
 
 // Notification is the object build and returned by GOV.UK Notify.
 type Notification struct {
-	ID        string    `json:"id"`
-	Body      string    `json:"body"`
-	Subject   string    `json:"subject"`
-	Reference string    `json:"reference"`
-	Email     string    `json:"email_address"`
-	Phone     string    `json:"phone_number"`
-	Line1     string    `json:"line_1"`
-	Line2     string    `json:"line_2"`
-	Line3     string    `json:"line_3"`
-	Line4     string    `json:"line_4"`
-	Line5     string    `json:"line_5"`
-	Line6     string    `json:"line_6"`
-	Postcode  string    `json:"postcode"`
-	Type      string    `json:"type"`
-	Status    string    `json:"status"`
-	Template  Template  `json:"template"`
-	CreatedAt time.Time `json:"created_at"`
-	SentAt    time.Time `json:"sent_at"`
+	ID                string       `json:"id"`
+	Body              string       `json:"body"`
+	Subject           string       `json:"subject"`
+	Reference         string       `json:"reference"`
+	Email             string       `json:"email_address"`
+	Phone             string       `json:"phone_number"`
+	Line1             string       `json:"line_1"`
+	Line2             string       `json:"line_2"`
+	Line3             string       `json:"line_3"`
+	Line4             string       `json:"line_4"`
+	Line5             string       `json:"line_5"`
+	Line6             string       `json:"line_6"`
+	Line7             string       `json:"line_7"`
+	Postcode          string       `json:"postcode"`
+	Postage           Postage      `json:"postage"`
+	Type              TemplateType `json:"type"`
+	Status            Status       `json:"status"`
+	Template          Template     `json:"template"`
+	CreatedAt         time.Time    `json:"created_at"`
+	CreatedByName     string       `json:"created_by_name"`
+	SentAt            *time.Time   `json:"sent_at"`
+	CompletedAt       *time.Time   `json:"completed_at"`
+	EstimatedDelivery *time.Time   `json:"estimated_delivery"`
+	IsCostDataReady   bool         `json:"is_cost_data_ready"`
+	CostInPounds      float64      `json:"cost_in_pounds"`
+	CostDetails       CostDetails  `json:"cost_details"`
+}
+
+// CostDetails of a Notification, once its IsCostDataReady. The fields set
+// depend on the type of the notification.
+type CostDetails struct {
+	BillableSmsFragments        int     `json:"billable_sms_fragments"`
+	InternationalRateMultiplier float64 `json:"international_rate_multiplier"`
+	SmsRate                     float64 `json:"sms_rate"`
+	BillableSheetsOfPaper       int     `json:"billable_sheets_of_paper"`
+	Postage                     Postage `json:"postage"`
 }
 
 // NotificationEntry is the struct aroung the successful response from the API
-// collected upon the creation of a new Notification.
+// collected upon the creation of a new Notification. It holds the fields
+// common to every type of notification.
 type NotificationEntry struct {
-	Content   map[string]string `json:"content"`
-	ID        string            `json:"id"`
-	Reference string            `json:"reference"`
-	Template  Template          `json:"template"`
-	URI       string            `json:"uri"`
+	ID           string   `json:"id"`
+	Reference    string   `json:"reference"`
+	Template     Template `json:"template"`
+	URI          string   `json:"uri"`
+	ScheduledFor string   `json:"scheduled_for"`
+}
+
+// EmailContent is the rendered email returned as part of EmailEntry.
+type EmailContent struct {
+	Body                   string `json:"body"`
+	Subject                string `json:"subject"`
+	FromEmail              string `json:"from_email"`
+	OneClickUnsubscribeURL string `json:"one_click_unsubscribe_url"`
+}
+
+// EmailEntry is the struct around the successful response from the API
+// collected upon the creation of a new email.
+type EmailEntry struct {
+	NotificationEntry
+	Content EmailContent `json:"content"`
+}
+
+// SmsContent is the rendered text message returned as part of SmsEntry.
+type SmsContent struct {
+	Body       string `json:"body"`
+	FromNumber string `json:"from_number"`
+}
+
+// SmsEntry is the struct around the successful response from the API
+// collected upon the creation of a new text message.
+type SmsEntry struct {
+	NotificationEntry
+	Content SmsContent `json:"content"`
 }
 
 // NotificationList is one the responses from GOV.UK Notify.
@@ -63,28 +111,107 @@ type NotificationList struct {
 
 // Next page of the list should be loaded in place of the old one.
 func (nl *NotificationList) Next() error {
+	return nl.NextContext(context.Background())
+}
+
+// NextContext is like Next, but uses ctx for the request.
+func (nl *NotificationList) NextContext(ctx context.Context) error {
 	if nl.Links.Next == "" {
 		return errors.New("pagination: already on last page")
 	}
 
-	res, err := nl.Client.httpGet(nl.Links.Next, nil)
-	if err != nil {
-		return err
-	}
-
-	return jsonResponse(res.Body, nl)
+	return nl.load(ctx, nl.Links.Next)
 }
 
 // Previous page of the list should be loaded in place of the old one.
 func (nl *NotificationList) Previous() error {
+	return nl.PreviousContext(context.Background())
+}
+
+// PreviousContext is like Previous, but uses ctx for the request.
+func (nl *NotificationList) PreviousContext(ctx context.Context) error {
 	if nl.Links.Previous == "" {
 		return errors.New("pagination: already on first page")
 	}
 
-	res, err := nl.Client.httpGet(nl.Links.Previous, nil)
+	return nl.load(ctx, nl.Links.Previous)
+}
+
+// load the page in place of the current one, leaving the list untouched when
+// it fails.
+func (nl *NotificationList) load(ctx context.Context, path string) error {
+	list := NotificationList{Client: nl.Client}
+
+	res, err := nl.Client.httpGet(ctx, path, nil)
 	if err != nil {
 		return err
 	}
 
-	return jsonResponse(res.Body, nl)
+	err = nl.Client.handleInvalidResponse(res)
+	if err != nil {
+		return err
+	}
+
+	err = jsonResponse(res.Body, &list)
+	if err != nil {
+		return err
+	}
+
+	*nl = list
+
+	return nil
+}
+
+// NotificationIterator walks through the notifications of every page of a
+// list, as returned by Client.IterateNotifications.
+type NotificationIterator struct {
+	ctx     context.Context
+	client  *Client
+	filters Filters
+
+	list  *NotificationList
+	index int
+	err   error
+}
+
+// Next advances the iterator to the next notification, loading the next page
+// when needed. It returns false when there are no more notifications or an
+// error occurred, which is then returned by Err.
+func (it *NotificationIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	it.index++
+	for it.list == nil || it.index >= len(it.list.Notifications) {
+		if it.list == nil {
+			it.list, it.err = it.client.ListNotificationsContext(it.ctx, it.filters)
+		} else if it.list.Links.Next == "" || len(it.list.Notifications) == 0 {
+			return false
+		} else {
+			it.err = it.list.NextContext(it.ctx)
+		}
+
+		if it.err != nil {
+			return false
+		}
+
+		it.index = 0
+	}
+
+	return true
+}
+
+// Notification the iterator is at, after a call to Next returned true.
+func (it *NotificationIterator) Notification() *Notification {
+	if it.list == nil || it.index >= len(it.list.Notifications) {
+		return nil
+	}
+
+	return &it.list.Notifications[it.index]
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *NotificationIterator) Err() error {
+	return it.err
 }
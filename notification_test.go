@@ -1,6 +1,9 @@
 package notify
 
 import (
+	"bytes"
+	"context"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 
@@ -10,6 +13,51 @@ import (
 )
 
 var _ = Describe("Notification", func() {
+	Context("Model", func() {
+		It("should decode a letter Notification from the API", func() {
+			s := `{"id":"740e5834-3a29-46b4-9a6f-16142fde533a","reference":null,"email_address":null,"phone_number":null,"line_1":"The Occupier","line_2":"123 High Street","line_3":null,"line_4":null,"line_5":null,"line_6":null,"line_7":"SW14 6BF","postcode":"SW14 6BF","postage":"second","type":"letter","status":"received","template":{"id":"f33517ff-2a88-4f6e-b855-c550268ce08a","version":2,"uri":"https://api.notifications.service.gov.uk/v2/template/f33517ff-2a88-4f6e-b855-c550268ce08a/version/2"},"body":"Dear occupier","subject":"Your appointment","created_at":"2024-05-17T15:58:38.342838Z","created_by_name":null,"sent_at":null,"completed_at":"2024-05-19T08:00:00.000000Z","estimated_delivery":"2024-05-21T15:00:00.000000Z","is_cost_data_ready":true,"cost_in_pounds":0.85,"cost_details":{"billable_sheets_of_paper":2,"postage":"second"}}`
+			n := Notification{}
+
+			err := jsonResponse(ioutil.NopCloser(bytes.NewReader([]byte(s))), &n)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(n.Template.ID).To(Equal("f33517ff-2a88-4f6e-b855-c550268ce08a"))
+			Expect(n.Type).To(Equal(TemplateTypeLetter))
+			Expect(n.Line7).To(Equal("SW14 6BF"))
+			Expect(n.Postage).To(Equal(PostageSecond))
+			Expect(n.SentAt).To(BeNil())
+			Expect(n.CompletedAt).NotTo(BeNil())
+			Expect(n.EstimatedDelivery).NotTo(BeNil())
+			Expect(n.IsCostDataReady).To(BeTrue())
+			Expect(n.CostInPounds).To(Equal(0.85))
+			Expect(n.CostDetails.BillableSheetsOfPaper).To(Equal(2))
+		})
+
+		It("should decode the content of an SmsEntry", func() {
+			s := `{"id":"740e5834-3a29-46b4-9a6f-16142fde533a","reference":"ref","content":{"body":"Hello","from_number":"GOVUK"},"uri":"https://api.notifications.service.gov.uk/v2/notifications/740e5834-3a29-46b4-9a6f-16142fde533a","template":{"id":"f33517ff-2a88-4f6e-b855-c550268ce08a","version":1,"uri":"https://api.notifications.service.gov.uk/v2/template/f33517ff-2a88-4f6e-b855-c550268ce08a"},"scheduled_for":null}`
+			e := SmsEntry{}
+
+			err := jsonResponse(ioutil.NopCloser(bytes.NewReader([]byte(s))), &e)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(e.ID).To(Equal("740e5834-3a29-46b4-9a6f-16142fde533a"))
+			Expect(e.Reference).To(Equal("ref"))
+			Expect(e.Content.FromNumber).To(Equal("GOVUK"))
+			Expect(e.Template.Version).To(Equal(int64(1)))
+		})
+
+		It("should decode the content of an EmailEntry", func() {
+			s := `{"id":"740e5834-3a29-46b4-9a6f-16142fde533a","content":{"body":"Hello","subject":"Hi","from_email":"service@notifications.service.gov.uk"}}`
+			e := EmailEntry{}
+
+			err := jsonResponse(ioutil.NopCloser(bytes.NewReader([]byte(s))), &e)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(e.Content.Subject).To(Equal("Hi"))
+			Expect(e.Content.FromEmail).To(Equal("service@notifications.service.gov.uk"))
+		})
+	})
+
 	Context("List", func() {
 		var (
 			client *Client
@@ -39,7 +87,7 @@ var _ = Describe("Notification", func() {
 			httpmock.RegisterResponder("GET", "https://example.com/v2/notifications?older_than=n0t1-0123456789&status=delivered",
 				httpmock.NewStringResponder(http.StatusOK, `{"notifications":[{"id":"n0t1-2345678901"}, {"id":"n0t1-9012345678"}],"links":{"current":"/v2/notifications?status=delivered","previous":"/v2/notifications?status=delivered"}}`))
 
-			list, err := client.ListNotifications(Filters{Status: "delivered"})
+			list, err := client.ListNotifications(Filters{Status: []Status{StatusDelivered}})
 
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(list.Notifications[0].ID).To(Equal("n0t1-1234567890"))
@@ -59,7 +107,7 @@ var _ = Describe("Notification", func() {
 			httpmock.RegisterResponder("GET", "https://example.com/v2/notifications",
 				httpmock.NewStringResponder(http.StatusOK, `{"notifications":[{"id":"n0t1-1234567890"}, {"id":"n0t1-0123456789"}],"links":{"current":"/v2/notifications?status=delivered","next":"/v2/notifications?older_than=n0t1-0123456789&status=delivered"}}`))
 
-			list, err := client.ListNotifications(Filters{OlderThan: "n0t1-0123456789", Status: "delivered"})
+			list, err := client.ListNotifications(Filters{OlderThan: "n0t1-0123456789", Status: []Status{StatusDelivered}})
 
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(list.Notifications[0].ID).To(Equal("n0t1-2345678901"))
@@ -77,7 +125,7 @@ var _ = Describe("Notification", func() {
 			httpmock.RegisterResponder("GET", "https://example.com/v2/notifications?status=delivered",
 				httpmock.NewStringResponder(http.StatusOK, `{"notifications":[{"id":"n0t1-2345678901"}, {"id":"n0t1-9012345678"}],"links":{"current":"/v2/notifications?status=delivered","previous":"/v2/notifications?status=delivered"}}`))
 
-			list, err := client.ListNotifications(Filters{Status: "delivered"})
+			list, err := client.ListNotifications(Filters{Status: []Status{StatusDelivered}})
 
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(list.Links.Next).To(BeEmpty())
@@ -91,7 +139,7 @@ var _ = Describe("Notification", func() {
 			httpmock.RegisterResponder("GET", "https://example.com/v2/notifications?status=delivered",
 				httpmock.NewStringResponder(http.StatusOK, `{"notifications":[{"id":"n0t1-1234567890"}, {"id":"n0t1-0123456789"}],"links":{"current":"/v2/notifications?status=delivered","next":"/v2/notifications?older_than=n0t1-0123456789&status=delivered"}}`))
 
-			list, err := client.ListNotifications(Filters{Status: "delivered"})
+			list, err := client.ListNotifications(Filters{Status: []Status{StatusDelivered}})
 
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(list.Links.Previous).To(BeEmpty())
@@ -101,4 +149,76 @@ var _ = Describe("Notification", func() {
 			Expect(err).Should(HaveOccurred())
 		})
 	})
+
+	Context("Iterator", func() {
+		var (
+			client *Client
+		)
+
+		BeforeEach(func() {
+			httpmock.Activate()
+
+			u, _ := url.Parse("https://example.com")
+
+			config := Configuration{
+				APIKey:    []byte("secret"),
+				BaseURL:   u,
+				ServiceID: "test",
+			}
+
+			client, _ = New(config)
+		})
+
+		AfterEach(func() {
+			httpmock.DeactivateAndReset()
+		})
+
+		It("should walk every page with IterateNotifications()", func() {
+			httpmock.RegisterResponder("GET", "https://example.com/v2/notifications?status=delivered",
+				httpmock.NewStringResponder(http.StatusOK, `{"notifications":[{"id":"n0t1-1"},{"id":"n0t1-2"}],"links":{"current":"https://example.com/v2/notifications?status=delivered","next":"https://example.com/v2/notifications?older_than=n0t1-2&status=delivered"}}`))
+			httpmock.RegisterResponder("GET", "https://example.com/v2/notifications?older_than=n0t1-2&status=delivered",
+				httpmock.NewStringResponder(http.StatusOK, `{"notifications":[{"id":"n0t1-3"}],"links":{"current":"/v2/notifications?older_than=n0t1-2&status=delivered","next":"/v2/notifications?older_than=n0t1-3&status=delivered"}}`))
+			httpmock.RegisterResponder("GET", "https://example.com/v2/notifications?older_than=n0t1-3&status=delivered",
+				httpmock.NewStringResponder(http.StatusOK, `{"notifications":[],"links":{"current":"/v2/notifications?older_than=n0t1-3&status=delivered"}}`))
+
+			it := client.IterateNotifications(context.Background(), Filters{Status: []Status{StatusDelivered}})
+
+			ids := []string{}
+			for it.Next() {
+				ids = append(ids, it.Notification().ID)
+			}
+
+			Expect(it.Err()).ShouldNot(HaveOccurred())
+			Expect(ids).To(Equal([]string{"n0t1-1", "n0t1-2", "n0t1-3"}))
+			Expect(it.Next()).To(BeFalse())
+		})
+
+		It("should stop IterateNotifications() on the first failed page", func() {
+			httpmock.RegisterResponder("GET", "https://example.com/v2/notifications",
+				httpmock.NewStringResponder(http.StatusOK, `{"notifications":[{"id":"n0t1-1"}],"links":{"next":"/v2/notifications?older_than=n0t1-1"}}`))
+			httpmock.RegisterResponder("GET", "https://example.com/v2/notifications?older_than=n0t1-1",
+				httpmock.NewStringResponder(http.StatusInternalServerError, `{"status_code":500,"errors":[{"error":"Exception","message":"Internal server error"}]}`))
+
+			it := client.IterateNotifications(context.Background(), Filters{})
+
+			Expect(it.Next()).To(BeTrue())
+			Expect(it.Notification().ID).To(Equal("n0t1-1"))
+			Expect(it.Next()).To(BeFalse())
+			Expect(it.Err()).To(BeAssignableToTypeOf(&ServerError{}))
+			Expect(it.Next()).To(BeFalse())
+		})
+
+		It("should leave the list untouched when Next() fails", func() {
+			httpmock.RegisterResponder("GET", "https://example.com/v2/notifications",
+				httpmock.NewStringResponder(http.StatusOK, `{"notifications":[{"id":"n0t1-1"}],"links":{"next":"/v2/notifications?older_than=n0t1-1"}}`))
+			httpmock.RegisterResponder("GET", "https://example.com/v2/notifications?older_than=n0t1-1",
+				httpmock.NewStringResponder(http.StatusBadGateway, `<html></html>`))
+
+			list, _ := client.ListNotifications(Filters{})
+			err := list.Next()
+
+			Expect(err).Should(HaveOccurred())
+			Expect(list.Notifications[0].ID).To(Equal("n0t1-1"))
+		})
+	})
 })
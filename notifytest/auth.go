@@ -0,0 +1,181 @@
+package notifytest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	notify "github.com/alphagov/notifications-go-client"
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+// KeyType of an API key, deciding who notifications can be sent to.
+type KeyType string
+
+// Types of API key issued by GOV.UK Notify.
+const (
+	// KeyTypeNormal keys send to anyone, unless the service is in trial mode.
+	KeyTypeNormal KeyType = "normal"
+	// KeyTypeTeam keys only send to the recipients in the AllowList.
+	KeyTypeTeam KeyType = "team"
+	// KeyTypeTest keys send to anyone, but the notifications only simulate
+	// their delivery, fail when sent to the simulated failure recipients and
+	// are only listed with a test key.
+	KeyTypeTest KeyType = "test"
+)
+
+// maxClockSkew is the difference allowed between the time a token is issued
+// at and the time it is received.
+const maxClockSkew = 30 * time.Second
+
+type apiKey struct {
+	name    string
+	keyType KeyType
+	secret  string
+}
+
+// AddAPIKey creates an API key of the type for the service, and returns it in
+// the format expected by notify.NewConfiguration.
+func (s *Server) AddAPIKey(name string, keyType KeyType) string {
+	key := &apiKey{
+		name:    name,
+		keyType: keyType,
+		secret:  newID(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.apiKeys = append(s.apiKeys, key)
+
+	return fmt.Sprintf("%s-%s-%s", key.name, s.configuration.ServiceID, key.secret)
+}
+
+// authenticate the request as GOV.UK Notify does, returning the API key that
+// signed its token.
+//
+// The token must be signed with HS256, issued by the service and issued at
+// within 30 seconds of the system clock, which the client signs tokens with.
+func (s *Server) authenticate(r *http.Request) (*apiKey, *requestError) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return nil, authError(http.StatusUnauthorized, "Unauthorized: authentication token must be provided")
+	}
+
+	if !strings.HasPrefix(header, "Bearer ") {
+		return nil, authError(http.StatusUnauthorized, "Unauthorized: authentication bearer scheme must be used")
+	}
+
+	token := strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+	parts := strings.Split(token, ".")
+
+	var (
+		head struct {
+			Alg string `json:"alg"`
+		}
+		claims struct {
+			Iss string   `json:"iss"`
+			Iat *float64 `json:"iat"`
+		}
+	)
+
+	if len(parts) != 3 || decodeSegment(parts[0], &head) != nil || decodeSegment(parts[1], &claims) != nil {
+		return nil, authError(http.StatusForbidden, "Invalid token: signature, api token is not valid")
+	}
+
+	if head.Alg != jwt.SigningMethodHS256.Alg() {
+		return nil, authError(http.StatusForbidden, "Invalid token: algorithm used is not HS256")
+	}
+
+	if claims.Iss == "" {
+		return nil, authError(http.StatusForbidden, "Invalid token: iss field not provided")
+	}
+
+	if claims.Iss != s.configuration.ServiceID {
+		return nil, authError(http.StatusForbidden, "Invalid token: service not found")
+	}
+
+	s.mu.Lock()
+	keys := append([]*apiKey{}, s.apiKeys...)
+	s.mu.Unlock()
+
+	if len(keys) == 0 {
+		return nil, authError(http.StatusForbidden, "Invalid token: service has no API keys")
+	}
+
+	var key *apiKey
+	for _, k := range keys {
+		if jwt.SigningMethodHS256.Verify(parts[0]+"."+parts[1], parts[2], []byte(k.secret)) == nil {
+			key = k
+			break
+		}
+	}
+
+	if key == nil {
+		return nil, authError(http.StatusForbidden, "Invalid token: API key not found")
+	}
+
+	if claims.Iat == nil {
+		return nil, authError(http.StatusForbidden, "Invalid token: iat field not provided")
+	}
+
+	skew := time.Duration(math.Abs(float64(time.Now().Unix())-*claims.Iat)) * time.Second
+	if skew > maxClockSkew {
+		return nil, authError(http.StatusForbidden, "Error: Your system clock must be accurate to within 30 seconds")
+	}
+
+	return key, nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	b, err := jwt.DecodeSegment(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+func authError(statusCode int, message string) *requestError {
+	return &requestError{statusCode, "AuthError", message}
+}
+
+// permit the notification to be sent with the API key, as restricted by its
+// type and by the trial mode of the service.
+func (s *Server) permit(key *apiKey, n *notification) *requestError {
+	if key.keyType == KeyTypeTest {
+		return nil
+	}
+
+	if n.templateType == notify.TemplateTypeLetter {
+		if key.keyType == KeyTypeTeam {
+			return &requestError{http.StatusForbidden, "BadRequestError", "Cannot send letters with a team api key"}
+		}
+
+		if s.configuration.TrialMode {
+			return badRequestError("Cannot send letters when service is in trial mode")
+		}
+
+		return nil
+	}
+
+	if key.keyType != KeyTypeTeam && !s.configuration.TrialMode {
+		return nil
+	}
+
+	recipient := recipientKey(n.recipient())
+	for _, allowed := range s.configuration.AllowList {
+		if recipientKey(allowed) == recipient {
+			return nil
+		}
+	}
+
+	if key.keyType == KeyTypeTeam {
+		return badRequestError("Can’t send to this recipient using a team-only API key")
+	}
+
+	return badRequestError("Can’t send to this recipient when service is in trial mode – see https://www.notifications.service.gov.uk/trial-mode")
+}
@@ -0,0 +1,223 @@
+package notifytest
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	notify "github.com/alphagov/notifications-go-client"
+	jwt "github.com/dgrijalva/jwt-go"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+// apiError returns the first error sent by the server.
+func apiError(err error) (int, string) {
+	var e *notify.APIError
+	Expect(errors.As(err, &e)).To(BeTrue())
+
+	return e.StatusCode, e.Errors[0].Message
+}
+
+var _ = Describe("Auth", func() {
+	var (
+		server *Server
+		apiKey string
+	)
+
+	BeforeEach(func() {
+		server, _ = newServer(Configuration{AllowList: []string{"betty@example.com", "07700 900123"}})
+		apiKey = server.AddAPIKey("test", KeyTypeNormal)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	// clientWithToken returns a client sending the token returned by sign.
+	clientWithToken := func(sign func(serviceID string, secret []byte) (string, error)) *notify.Client {
+		config, _ := notify.NewConfiguration(apiKey)
+		config.BaseURL, _ = url.Parse(server.URL)
+		config.TokenSource = tokenSourceFunc(func() (string, error) {
+			return sign(config.ServiceID, config.APIKey)
+		})
+
+		client, _ := notify.New(config)
+
+		return client
+	}
+
+	signed := func(method jwt.SigningMethod, claims jwt.MapClaims) func(string, []byte) (string, error) {
+		return func(serviceID string, secret []byte) (string, error) {
+			if _, ok := claims["iss"]; !ok {
+				claims["iss"] = serviceID
+			}
+
+			return jwt.NewWithClaims(method, claims).SignedString(secret)
+		}
+	}
+
+	It("should accept a token signed with an API key", func() {
+		_, err := newClient(server, apiKey).ListNotifications(notify.Filters{})
+
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	DescribeTable("should reject an invalid token",
+		func(sign func(string, []byte) (string, error), statusCode int, message string) {
+			_, err := clientWithToken(sign).ListNotifications(notify.Filters{})
+
+			var e *notify.AuthError
+			Expect(errors.As(err, &e)).To(BeTrue())
+
+			s, m := apiError(err)
+			Expect(s).To(Equal(statusCode))
+			Expect(m).To(Equal(message))
+		},
+		Entry("malformed", func(string, []byte) (string, error) {
+			return "token", nil
+		}, http.StatusForbidden, "Invalid token: signature, api token is not valid"),
+		Entry("not HS256", signed(jwt.SigningMethodHS512, jwt.MapClaims{"iat": time.Now().Unix()}),
+			http.StatusForbidden, "Invalid token: algorithm used is not HS256"),
+		Entry("without iss", signed(jwt.SigningMethodHS256, jwt.MapClaims{"iss": "", "iat": time.Now().Unix()}),
+			http.StatusForbidden, "Invalid token: iss field not provided"),
+		Entry("issued by another service", signed(jwt.SigningMethodHS256, jwt.MapClaims{"iss": "other", "iat": time.Now().Unix()}),
+			http.StatusForbidden, "Invalid token: service not found"),
+		Entry("signed with another key", func(serviceID string, _ []byte) (string, error) {
+			return signed(jwt.SigningMethodHS256, jwt.MapClaims{"iat": time.Now().Unix()})(serviceID, []byte("other"))
+		}, http.StatusForbidden, "Invalid token: API key not found"),
+		Entry("without iat", signed(jwt.SigningMethodHS256, jwt.MapClaims{}),
+			http.StatusForbidden, "Invalid token: iat field not provided"),
+		Entry("issued too long ago", signed(jwt.SigningMethodHS256, jwt.MapClaims{"iat": time.Now().Add(-time.Minute).Unix()}),
+			http.StatusForbidden, "Error: Your system clock must be accurate to within 30 seconds"),
+		Entry("issued in the future", signed(jwt.SigningMethodHS256, jwt.MapClaims{"iat": time.Now().Add(time.Minute).Unix()}),
+			http.StatusForbidden, "Error: Your system clock must be accurate to within 30 seconds"),
+	)
+
+	It("should reject a request without the bearer scheme", func() {
+		req, _ := http.NewRequest("GET", server.URL+"/v2/notifications", nil)
+		req.Header.Set("Authorization", "Basic dGVzdDp0ZXN0")
+
+		res, err := http.DefaultClient.Do(req)
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(res.StatusCode).To(Equal(http.StatusUnauthorized))
+	})
+
+	It("should reject a token when the service has no API keys", func() {
+		other := NewServer(Configuration{})
+		defer other.Close()
+
+		config, _ := notify.NewConfiguration("test-" + newID() + "-" + newID())
+		config.ServiceID = other.configuration.ServiceID
+		config.BaseURL, _ = url.Parse(other.URL)
+		client, _ := notify.New(config)
+
+		_, err := client.ListNotifications(notify.Filters{})
+
+		_, m := apiError(err)
+		Expect(m).To(Equal("Invalid token: service has no API keys"))
+	})
+
+	Context("with a team key", func() {
+		var client *notify.Client
+
+		BeforeEach(func() {
+			client = newClient(server, server.AddAPIKey("team", KeyTypeTeam))
+		})
+
+		It("should send to the allow list", func() {
+			_, err := client.SendEmail("Betty@example.com", emailTemplateID, map[string]interface{}{"name": "Betty", "ref": "A1"}, "")
+			Expect(err).ShouldNot(HaveOccurred())
+
+			_, err = client.SendSms("+447700900123", smsTemplateID, map[string]interface{}{"code": 1}, "")
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		It("should not send to anyone else", func() {
+			_, err := client.SendEmail("bob@example.com", emailTemplateID, map[string]interface{}{"name": "Bob", "ref": "A1"}, "")
+
+			var e *notify.BadRequestError
+			Expect(errors.As(err, &e)).To(BeTrue())
+			Expect(e.Errors[0].Message).To(Equal("Can’t send to this recipient using a team-only API key"))
+			Expect(server.Notifications()).To(BeEmpty())
+		})
+
+		It("should not send letters", func() {
+			address := notify.LetterAddress{AddressLine1: "Betty Smith", AddressLine2: "1 Example Street", AddressLine3: "SW1A 1AA"}
+			_, err := client.SendLetter(address, letterTemplateID, nil, "", "")
+
+			s, m := apiError(err)
+			Expect(s).To(Equal(http.StatusForbidden))
+			Expect(m).To(Equal("Cannot send letters with a team api key"))
+		})
+	})
+
+	Context("in trial mode", func() {
+		BeforeEach(func() {
+			server.Close()
+			server, _ = newServer(Configuration{TrialMode: true, AllowList: []string{"betty@example.com"}})
+			apiKey = server.AddAPIKey("live", KeyTypeNormal)
+		})
+
+		It("should only send to the allow list", func() {
+			client := newClient(server, apiKey)
+
+			_, err := client.SendEmail("betty@example.com", emailTemplateID, map[string]interface{}{"name": "Betty", "ref": "A1"}, "")
+			Expect(err).ShouldNot(HaveOccurred())
+
+			_, err = client.SendEmail("bob@example.com", emailTemplateID, map[string]interface{}{"name": "Bob", "ref": "A1"}, "")
+
+			var e *notify.BadRequestError
+			Expect(errors.As(err, &e)).To(BeTrue())
+			Expect(e.Errors[0].Message).To(ContainSubstring("when service is in trial mode"))
+		})
+
+		It("should not send letters", func() {
+			address := notify.LetterAddress{AddressLine1: "Betty Smith", AddressLine2: "1 Example Street", AddressLine3: "SW1A 1AA"}
+			_, err := newClient(server, apiKey).SendLetter(address, letterTemplateID, nil, "", "")
+
+			var e *notify.BadRequestError
+			Expect(errors.As(err, &e)).To(BeTrue())
+
+			s, m := apiError(err)
+			Expect(s).To(Equal(http.StatusBadRequest))
+			Expect(m).To(Equal("Cannot send letters when service is in trial mode"))
+		})
+
+		It("should send to anyone with a test key", func() {
+			client := newClient(server, server.AddAPIKey("test", KeyTypeTest))
+
+			_, err := client.SendEmail("bob@example.com", emailTemplateID, map[string]interface{}{"name": "Bob", "ref": "A1"}, "")
+
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+	})
+
+	It("should only list the notifications sent with the same type of key", func() {
+		live := newClient(server, apiKey)
+		test := newClient(server, server.AddAPIKey("test", KeyTypeTest))
+
+		live.SendEmail("bob@example.com", emailTemplateID, map[string]interface{}{"name": "Bob", "ref": "A1"}, "live")
+		test.SendEmail("bob@example.com", emailTemplateID, map[string]interface{}{"name": "Bob", "ref": "A1"}, "test")
+
+		list, err := test.ListNotifications(notify.Filters{})
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(list.Notifications).To(HaveLen(1))
+		Expect(list.Notifications[0].Reference).To(Equal("test"))
+
+		_, err = live.GetNotification(list.Notifications[0].ID)
+
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+})
+
+type tokenSourceFunc func() (string, error)
+
+func (f tokenSourceFunc) Token() (string, error) {
+	return f()
+}
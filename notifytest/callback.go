@@ -0,0 +1,169 @@
+package notifytest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	notify "github.com/alphagov/notifications-go-client"
+)
+
+// Callback the fake server posts to, as configured for a service in GOV.UK
+// Notify.
+type Callback struct {
+	URL string
+	// BearerToken sent in the Authorization header of every callback.
+	BearerToken string
+}
+
+// updateInterval is how often the statuses are updated with the system clock,
+// so that delivery receipts are posted without the server being used.
+const updateInterval = 100 * time.Millisecond
+
+// deliveryReceipt posted to the DeliveryReceipts callback.
+type deliveryReceipt struct {
+	ID               string              `json:"id"`
+	Reference        *string             `json:"reference"`
+	To               string              `json:"to"`
+	Status           notify.Status       `json:"status"`
+	CreatedAt        string              `json:"created_at"`
+	CompletedAt      *string             `json:"completed_at"`
+	SentAt           *string             `json:"sent_at"`
+	NotificationType notify.TemplateType `json:"notification_type"`
+	TemplateID       string              `json:"template_id"`
+	TemplateVersion  int64               `json:"template_version"`
+}
+
+// inboundSms posted to the ReceivedTextMessages callback.
+type inboundSms struct {
+	ID                string `json:"id"`
+	SourceNumber      string `json:"source_number"`
+	DestinationNumber string `json:"destination_number"`
+	Message           string `json:"message"`
+	DateReceived      string `json:"date_received"`
+}
+
+type callback struct {
+	target *Callback
+	body   []byte
+}
+
+// receipt queues the delivery receipt of the notification, once it reaches
+// its outcome. GOV.UK Notify only sends receipts for emails and text messages.
+// The caller must hold s.mu.
+func (s *Server) receipt(n *notification) {
+	if !n.status.IsFinal() || n.templateType == notify.TemplateTypeLetter {
+		return
+	}
+
+	s.enqueue(s.configuration.DeliveryReceipts, deliveryReceipt{
+		ID:               n.id,
+		Reference:        optional(n.reference),
+		To:               n.recipient(),
+		Status:           n.status,
+		CreatedAt:        n.createdAt.UTC().Format(timeFormat),
+		CompletedAt:      optionalTime(n.completedAt),
+		SentAt:           optionalTime(n.sentAt),
+		NotificationType: n.templateType,
+		TemplateID:       n.template.ID,
+		TemplateVersion:  n.template.Version,
+	})
+}
+
+// enqueue the payload to be posted to the callback, if it is configured.
+func (s *Server) enqueue(target *Callback, payload interface{}) {
+	if target == nil || target.URL == "" {
+		return
+	}
+
+	body, _ := json.Marshal(payload)
+
+	s.queueMu.Lock()
+	defer s.queueMu.Unlock()
+
+	if s.closed {
+		return
+	}
+
+	s.queue = append(s.queue, callback{target, body})
+	s.queueCond.Broadcast()
+}
+
+// deliver the callbacks in the order they were queued, until the server is
+// closed.
+func (s *Server) deliver() {
+	s.queueMu.Lock()
+	defer s.queueMu.Unlock()
+
+	for {
+		for len(s.queue) == 0 && !s.closed {
+			s.queueCond.Wait()
+		}
+
+		if s.closed {
+			return
+		}
+
+		cb := s.queue[0]
+
+		s.queueMu.Unlock()
+		s.post(cb)
+		s.queueMu.Lock()
+
+		s.queue = s.queue[1:]
+		s.queueCond.Broadcast()
+	}
+}
+
+func (s *Server) post(cb callback) {
+	req, err := http.NewRequest(http.MethodPost, cb.target.URL, bytes.NewReader(cb.body))
+	if err != nil {
+		return
+	}
+
+	req.Header.Set("Authorization", "Bearer "+cb.target.BearerToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := s.configuration.CallbackClient.Do(req)
+	if err != nil {
+		return
+	}
+
+	io.Copy(ioutil.Discard, res.Body)
+	res.Body.Close()
+}
+
+// Flush updates the status of every notification, then waits for the
+// callbacks queued to be posted.
+func (s *Server) Flush() {
+	s.mu.Lock()
+	s.update()
+	s.mu.Unlock()
+
+	s.queueMu.Lock()
+	defer s.queueMu.Unlock()
+
+	for len(s.queue) > 0 && !s.closed {
+		s.queueCond.Wait()
+	}
+}
+
+// tick updates the statuses with the system clock until the server is closed.
+func (s *Server) tick() {
+	ticker := time.NewTicker(updateInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.mu.Lock()
+			s.update()
+			s.mu.Unlock()
+		case <-s.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package notifytest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	notify "github.com/alphagov/notifications-go-client"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// receiver of the callbacks, recording their bearer token and payload.
+type receiver struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	tokens    []string
+	callbacks []map[string]interface{}
+}
+
+func newReceiver() *receiver {
+	rec := &receiver{}
+	rec.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&payload)
+
+		rec.mu.Lock()
+		rec.tokens = append(rec.tokens, r.Header.Get("Authorization"))
+		rec.callbacks = append(rec.callbacks, payload)
+		rec.mu.Unlock()
+	}))
+
+	return rec
+}
+
+func (rec *receiver) received() []map[string]interface{} {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	return append([]map[string]interface{}{}, rec.callbacks...)
+}
+
+var _ = Describe("Callback", func() {
+	var (
+		server   *Server
+		client   *notify.Client
+		clock    *Clock
+		receipts *receiver
+		inbound  *receiver
+	)
+
+	BeforeEach(func() {
+		clock = NewClock(time.Now())
+		receipts = newReceiver()
+		inbound = newReceiver()
+
+		server, client = newServer(Configuration{
+			Clock:                clock,
+			DeliveryReceipts:     &Callback{URL: receipts.URL + "/receipts", BearerToken: "receipts-token"},
+			ReceivedTextMessages: &Callback{URL: inbound.URL + "/inbound", BearerToken: "inbound-token"},
+		})
+	})
+
+	AfterEach(func() {
+		server.Close()
+		receipts.Close()
+		inbound.Close()
+	})
+
+	It("should post a delivery receipt when a notification reaches its outcome", func() {
+		server.SetOutcome("07700900123", notify.StatusPermanentFailure)
+
+		res, _ := client.SendSms("07700900123", smsTemplateID, map[string]interface{}{"code": 1}, "ref-1")
+
+		clock.Advance(DefaultSendingDelay)
+		server.Flush()
+
+		Expect(receipts.received()).To(BeEmpty())
+
+		clock.Advance(DefaultDeliveryDelay)
+		server.Flush()
+
+		Expect(receipts.received()).To(HaveLen(1))
+		Expect(receipts.tokens[0]).To(Equal("Bearer receipts-token"))
+
+		receipt := receipts.received()[0]
+		Expect(receipt["id"]).To(Equal(res.ID))
+		Expect(receipt["reference"]).To(Equal("ref-1"))
+		Expect(receipt["to"]).To(Equal("07700900123"))
+		Expect(receipt["status"]).To(Equal("permanent-failure"))
+		Expect(receipt["notification_type"]).To(Equal("sms"))
+		Expect(receipt["template_id"]).To(Equal(smsTemplateID))
+		Expect(receipt["completed_at"]).NotTo(BeNil())
+	})
+
+	It("should not post a delivery receipt for a letter", func() {
+		address := notify.LetterAddress{AddressLine1: "Betty Smith", AddressLine2: "1 Example Street", AddressLine3: "SW1A 1AA"}
+		client.SendLetter(address, letterTemplateID, nil, "", "")
+
+		clock.Advance(DefaultDeliveryDelay)
+		server.Flush()
+
+		Expect(receipts.received()).To(BeEmpty())
+	})
+
+	It("should not post a delivery receipt for a smoke test recipient", func() {
+		client.SendSms("07700900111", smsTemplateID, map[string]interface{}{"code": 1}, "")
+
+		clock.Advance(DefaultDeliveryDelay)
+		server.Flush()
+
+		Expect(receipts.received()).To(BeEmpty())
+	})
+
+	It("should post delivery receipts with the system clock", func() {
+		server.Close()
+		server, client = newServer(Configuration{
+			SendingDelay:     time.Millisecond,
+			DeliveryDelay:    10 * time.Millisecond,
+			DeliveryReceipts: &Callback{URL: receipts.URL, BearerToken: "receipts-token"},
+		})
+
+		client.SendEmail("betty@example.com", emailTemplateID, map[string]interface{}{"name": "Betty", "ref": "A1"}, "")
+
+		Eventually(receipts.received).Should(HaveLen(1))
+		Expect(receipts.received()[0]["status"]).To(Equal("delivered"))
+	})
+
+	It("should post a text message received", func() {
+		m := server.ReceiveTextMessage("07700 900111", "Hello")
+		server.Flush()
+
+		Expect(inbound.received()).To(HaveLen(1))
+		Expect(inbound.tokens[0]).To(Equal("Bearer inbound-token"))
+
+		callback := inbound.received()[0]
+		Expect(callback["id"]).To(Equal(m.ID))
+		Expect(callback["source_number"]).To(Equal("447700900111"))
+		Expect(callback["destination_number"]).To(Equal("07700900500"))
+		Expect(callback["message"]).To(Equal("Hello"))
+		Expect(callback["date_received"]).NotTo(BeEmpty())
+	})
+
+	It("should receive a text message on its endpoint", func() {
+		body := bytes.NewBufferString(`{"user_number":"07700900111","content":"Hello"}`)
+
+		res, err := http.Post(server.URL+PathReceiveTextMessage, "application/json", body)
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(res.StatusCode).To(Equal(http.StatusCreated))
+
+		b, _ := ioutil.ReadAll(res.Body)
+		Expect(string(b)).To(ContainSubstring(`"content":"Hello"`))
+
+		server.Flush()
+		Expect(inbound.received()).To(HaveLen(1))
+
+		list, err := client.ListReceivedTextMessages("")
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(list.ReceivedTextMessages).To(HaveLen(1))
+		Expect(list.ReceivedTextMessages[0].UserNumber).To(Equal("447700900111"))
+		Expect(list.ReceivedTextMessages[0].Content).To(Equal("Hello"))
+	})
+
+	It("should reject a text message from an invalid number", func() {
+		body := bytes.NewBufferString(`{"user_number":"hello","content":"Hello"}`)
+
+		res, err := http.Post(server.URL+PathReceiveTextMessage, "application/json", body)
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(res.StatusCode).To(Equal(http.StatusBadRequest))
+	})
+
+	It("should list the text messages received, a page at a time", func() {
+		server.Close()
+		server, client = newServer(Configuration{PageSize: 1})
+
+		first := server.ReceiveTextMessage("07700900111", "First")
+		server.ReceiveTextMessage("07700900111", "Second")
+
+		list, err := client.ListReceivedTextMessages("")
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(list.ReceivedTextMessages[0].Content).To(Equal("Second"))
+
+		err = list.Next()
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(list.ReceivedTextMessages[0].ID).To(Equal(first.ID))
+	})
+})
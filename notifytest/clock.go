@@ -0,0 +1,62 @@
+package notifytest
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is a clock controlled by the test, driving the delivery of the
+// notifications sent to the servers using it. It is safe for concurrent use.
+type Clock struct {
+	mu        sync.Mutex
+	now       time.Time
+	listeners []*func()
+}
+
+// NewClock returns a Clock set to the time passed.
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+// Now returns the current time of the clock.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// Advance the clock by the duration, updating the status of the notifications
+// sent to the servers using it.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	listeners := append([]*func(){}, c.listeners...)
+	c.mu.Unlock()
+
+	for _, f := range listeners {
+		(*f)()
+	}
+}
+
+// subscribe calls f every time the clock is advanced, until the function it
+// returns is called.
+func (c *Clock) subscribe(f func()) (unsubscribe func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	listener := &f
+	c.listeners = append(c.listeners, listener)
+
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		for i, l := range c.listeners {
+			if l == listener {
+				c.listeners = append(c.listeners[:i], c.listeners[i+1:]...)
+				return
+			}
+		}
+	}
+}
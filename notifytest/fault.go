@@ -0,0 +1,204 @@
+package notifytest
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FaultKind is the failure injected by a Fault.
+type FaultKind string
+
+// Kinds of failure a Fault may inject.
+const (
+	// FaultLatency only delays the response by the Latency of the Fault.
+	FaultLatency FaultKind = "latency"
+	// FaultInternalServerError responds with a 500 from the API.
+	FaultInternalServerError FaultKind = "internal-server-error"
+	// FaultBadGateway responds with a 502 HTML page from the load balancer.
+	FaultBadGateway FaultKind = "bad-gateway"
+	// FaultServiceUnavailable responds with a 503 HTML page from the load
+	// balancer.
+	FaultServiceUnavailable FaultKind = "service-unavailable"
+	// FaultConnectionReset resets the connection without responding.
+	FaultConnectionReset FaultKind = "connection-reset"
+	// FaultMalformedJSON handles the request, then cuts the JSON of the
+	// response in half.
+	FaultMalformedJSON FaultKind = "malformed-json"
+	// FaultRateLimit responds with the 429 RateLimitError of an API key over
+	// its rate limit.
+	FaultRateLimit FaultKind = "rate-limit"
+	// FaultTooManyRequests responds with the 429 TooManyRequestsError of a
+	// service over its daily limit.
+	FaultTooManyRequests FaultKind = "too-many-requests"
+)
+
+// Fault injected into the requests matching its Method and Path.
+type Fault struct {
+	Kind FaultKind
+	// Method of the requests to inject the fault into, any when empty.
+	Method string
+	// Path prefix of the requests to inject the fault into, any when empty.
+	Path string
+	// Probability of injecting the fault into a matching request, between 0
+	// and 1. The fault is injected into every matching request when 0.
+	Probability float64
+	// Times is the number of requests to inject the fault into, after which
+	// it is removed. The fault is never removed when 0.
+	Times int
+	// Latency before responding, or failing.
+	Latency time.Duration
+	// Processed makes the server handle the request before failing, as when
+	// the response is lost after a notification has been sent.
+	Processed bool
+	// RetryAfter is sent in the Retry-After header of the response when set.
+	RetryAfter time.Duration
+}
+
+// InjectFault adds the fault to the server. The faults are matched in the
+// order they were added, and only one is injected into a request.
+func (s *Server) InjectFault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes every fault from the server.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = nil
+}
+
+// fault to inject into the request, if any.
+func (s *Server) fault(r *http.Request) *Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, f := range s.faults {
+		if f.Method != "" && !strings.EqualFold(f.Method, r.Method) {
+			continue
+		}
+
+		if !strings.HasPrefix(r.URL.Path, f.Path) {
+			continue
+		}
+
+		if f.Probability > 0 && s.rand.Float64() >= f.Probability {
+			continue
+		}
+
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.faults = append(s.faults[:i:i], s.faults[i+1:]...)
+			}
+		}
+
+		return f
+	}
+
+	return nil
+}
+
+// inject the fault into the response to the request.
+func (s *Server) inject(w http.ResponseWriter, r *http.Request, f *Fault) {
+	if f.Latency > 0 {
+		select {
+		case <-time.After(f.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	switch f.Kind {
+	case FaultLatency:
+		s.serve(w, r)
+		return
+	case FaultMalformedJSON:
+		rec := httptest.NewRecorder()
+		s.serve(rec, r)
+
+		for k, v := range rec.Header() {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(rec.Code)
+
+		body := rec.Body.Bytes()
+		w.Write(body[:len(body)/2])
+		return
+	}
+
+	if f.Processed {
+		s.serve(httptest.NewRecorder(), r)
+	}
+
+	if f.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(f.RetryAfter.Seconds())))
+	}
+
+	switch f.Kind {
+	case FaultInternalServerError:
+		writeJSON(w, http.StatusInternalServerError, map[string]string{
+			"result":  "error",
+			"message": "Internal server error",
+		})
+	case FaultBadGateway:
+		writeHTML(w, http.StatusBadGateway, "502 Bad Gateway")
+	case FaultServiceUnavailable:
+		writeHTML(w, http.StatusServiceUnavailable, "503 Service Temporarily Unavailable")
+	case FaultConnectionReset:
+		reset(w)
+	case FaultRateLimit:
+		keyType := KeyTypeNormal
+		if key, err := s.authenticate(r); err == nil {
+			keyType = key.keyType
+		}
+
+		s.rateLimitError(keyType).write(w)
+	case FaultTooManyRequests:
+		s.tooManyRequestsError().write(w)
+	}
+}
+
+func writeHTML(w http.ResponseWriter, statusCode int, title string) {
+	w.Header().Set("Content-Type", "text/html")
+	w.WriteHeader(statusCode)
+
+	fmt.Fprintf(w, "<html>\r\n<head><title>%s</title></head>\r\n<body>\r\n<center><h1>%s</h1></center>\r\n</body>\r\n</html>\r\n", title, title)
+}
+
+// reset the connection of the response, so that the client gets a connection
+// reset by peer rather than a response. A server error explaining why is
+// written instead when the connection can not be taken over.
+func reset(w http.ResponseWriter) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{
+			"result":  "error",
+			"message": "notifytest: the connection can not be reset",
+		})
+		return
+	}
+
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{
+			"result":  "error",
+			"message": "notifytest: the connection can not be reset: " + err.Error(),
+		})
+		return
+	}
+
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		tcp.SetLinger(0)
+	}
+
+	conn.Close()
+}
@@ -0,0 +1,253 @@
+package notifytest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	notify "github.com/alphagov/notifications-go-client"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Fault", func() {
+	var (
+		server *Server
+		client *notify.Client
+		apiKey string
+	)
+
+	personalisation := map[string]interface{}{"code": 1}
+
+	// retryingClient returns a client retrying requests quickly.
+	retryingClient := func() *notify.Client {
+		config, _ := notify.NewConfiguration(apiKey)
+		config.BaseURL, _ = url.Parse(server.URL)
+		config.RetryPolicy = &notify.RetryPolicy{
+			MaxAttempts: 3,
+			BaseDelay:   time.Millisecond,
+			MaxDelay:    5 * time.Millisecond,
+		}
+
+		client, _ := notify.New(config)
+
+		return client
+	}
+
+	BeforeEach(func() {
+		server, client = newServer(Configuration{Seed: 1})
+		apiKey = server.AddAPIKey("retrying", KeyTypeNormal)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should respond with a server error", func() {
+		server.InjectFault(Fault{Kind: FaultInternalServerError})
+
+		_, err := client.SendSms("07700900123", smsTemplateID, personalisation, "")
+
+		var e *notify.ServerError
+		Expect(errors.As(err, &e)).To(BeTrue())
+		Expect(e.StatusCode).To(Equal(http.StatusInternalServerError))
+		Expect(server.Notifications()).To(BeEmpty())
+	})
+
+	It("should respond with an HTML page from the load balancer", func() {
+		server.InjectFault(Fault{Kind: FaultBadGateway})
+
+		_, err := client.ListNotifications(notify.Filters{})
+
+		var e *notify.ServerError
+		Expect(errors.As(err, &e)).To(BeTrue())
+		Expect(e.StatusCode).To(Equal(http.StatusBadGateway))
+		Expect(e.Body).To(ContainSubstring("<title>502 Bad Gateway</title>"))
+	})
+
+	It("should reset the connection", func() {
+		server.InjectFault(Fault{Kind: FaultConnectionReset})
+
+		_, err := client.ListNotifications(notify.Filters{})
+
+		var e *notify.APIError
+		Expect(err).Should(HaveOccurred())
+		Expect(errors.As(err, &e)).To(BeFalse())
+	})
+
+	It("should respond with a server error when the connection can not be reset", func() {
+		w := httptest.NewRecorder()
+
+		reset(w)
+
+		Expect(w.Code).To(Equal(http.StatusInternalServerError))
+		Expect(w.Body.String()).To(ContainSubstring("the connection can not be reset"))
+	})
+
+	It("should cut the JSON of the response in half", func() {
+		server.InjectFault(Fault{Kind: FaultMalformedJSON})
+
+		_, err := client.SendSms("07700900123", smsTemplateID, personalisation, "")
+
+		Expect(err).Should(HaveOccurred())
+		Expect(server.Notifications()).To(HaveLen(1))
+	})
+
+	It("should delay the response", func() {
+		server.InjectFault(Fault{Kind: FaultLatency, Latency: 50 * time.Millisecond})
+
+		start := time.Now()
+		_, err := client.ListNotifications(notify.Filters{})
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(time.Since(start)).To(BeNumerically(">=", 50*time.Millisecond))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err = client.ListNotificationsContext(ctx, notify.Filters{})
+
+		Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+	})
+
+	It("should respond with a rate limit error", func() {
+		server.InjectFault(Fault{Kind: FaultRateLimit, RetryAfter: time.Second})
+
+		_, err := client.SendSms("07700900123", smsTemplateID, personalisation, "")
+
+		var e *notify.RateLimitError
+		Expect(errors.As(err, &e)).To(BeTrue())
+		Expect(e.Errors[0].Message).To(Equal("Exceeded rate limit for key type NORMAL of 3000 requests per 60 seconds"))
+	})
+
+	It("should respond with a daily limit error", func() {
+		server.InjectFault(Fault{Kind: FaultTooManyRequests})
+
+		_, err := retryingClient().SendSms("07700900123", smsTemplateID, personalisation, "ref-1")
+
+		var e *notify.TooManyRequestsError
+		Expect(errors.As(err, &e)).To(BeTrue())
+		Expect(e.Errors[0].Message).To(Equal("Exceeded send limits (250000) for today"))
+	})
+
+	It("should only inject a fault into the matching requests", func() {
+		server.InjectFault(Fault{Kind: FaultServiceUnavailable, Method: "POST", Path: "/v2/notifications/sms"})
+
+		_, err := client.SendEmail("betty@example.com", emailTemplateID, map[string]interface{}{"name": "Betty", "ref": "A1"}, "")
+		Expect(err).ShouldNot(HaveOccurred())
+
+		_, err = client.ListNotifications(notify.Filters{})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		_, err = client.SendSms("07700900123", smsTemplateID, personalisation, "")
+		Expect(err).Should(HaveOccurred())
+	})
+
+	It("should inject a fault the number of times set", func() {
+		server.InjectFault(Fault{Kind: FaultServiceUnavailable, Method: "POST", Times: 2})
+
+		_, err := retryingClient().SendSms("07700900123", smsTemplateID, personalisation, "ref-1")
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(server.Notifications()).To(HaveLen(1))
+	})
+
+	It("should not send twice when the response to a send is lost", func() {
+		server.InjectFault(Fault{Kind: FaultBadGateway, Method: "POST", Processed: true, Times: 1})
+
+		res, err := retryingClient().SendSms("07700900123", smsTemplateID, personalisation, "ref-1")
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(server.Notifications()).To(HaveLen(1))
+		Expect(res.ID).To(Equal(server.Notifications()[0].ID))
+	})
+
+	It("should inject a fault by probability", func() {
+		server.InjectFault(Fault{Kind: FaultInternalServerError, Probability: 0.5})
+
+		failed := 0
+		for i := 0; i < 50; i++ {
+			if _, err := client.ListNotifications(notify.Filters{}); err != nil {
+				failed++
+			}
+		}
+
+		Expect(failed).To(BeNumerically(">", 10))
+		Expect(failed).To(BeNumerically("<", 40))
+	})
+
+	It("should clear the faults", func() {
+		server.InjectFault(Fault{Kind: FaultInternalServerError})
+		server.ClearFaults()
+
+		_, err := client.ListNotifications(notify.Filters{})
+
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+})
+
+var _ = Describe("Limit", func() {
+	var (
+		server *Server
+		client *notify.Client
+		clock  *Clock
+	)
+
+	personalisation := map[string]interface{}{"code": 1}
+
+	BeforeEach(func() {
+		clock = NewClock(time.Now())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should limit the rate of sends with an API key", func() {
+		server, client = newServer(Configuration{Clock: clock, RateLimit: 2, RateLimitInterval: 10 * time.Second})
+
+		for i := 0; i < 2; i++ {
+			_, err := client.SendSms("07700900123", smsTemplateID, personalisation, "")
+			Expect(err).ShouldNot(HaveOccurred())
+		}
+
+		_, err := client.SendSms("07700900123", smsTemplateID, personalisation, "")
+
+		var e *notify.RateLimitError
+		Expect(errors.As(err, &e)).To(BeTrue())
+		Expect(e.Errors[0].Message).To(Equal("Exceeded rate limit for key type NORMAL of 2 requests per 10 seconds"))
+
+		_, err = newClient(server, server.AddAPIKey("other", KeyTypeNormal)).SendSms("07700900123", smsTemplateID, personalisation, "")
+		Expect(err).ShouldNot(HaveOccurred())
+
+		clock.Advance(10 * time.Second)
+
+		_, err = client.SendSms("07700900123", smsTemplateID, personalisation, "")
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	It("should limit the sends of the service in a day", func() {
+		server, client = newServer(Configuration{Clock: clock, DailyLimit: 1})
+
+		_, err := client.SendSms("07700900123", smsTemplateID, personalisation, "")
+		Expect(err).ShouldNot(HaveOccurred())
+
+		_, err = client.SendSms("07700900123", smsTemplateID, personalisation, "")
+
+		var e *notify.TooManyRequestsError
+		Expect(errors.As(err, &e)).To(BeTrue())
+		Expect(e.Errors[0].Message).To(Equal("Exceeded send limits (1) for today"))
+
+		_, err = newClient(server, server.AddAPIKey("test", KeyTypeTest)).SendSms("07700900123", smsTemplateID, personalisation, "")
+		Expect(err).ShouldNot(HaveOccurred())
+
+		clock.Advance(24 * time.Hour)
+
+		_, err = client.SendSms("07700900123", smsTemplateID, personalisation, "")
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+})
@@ -0,0 +1,167 @@
+package notifytest
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	notify "github.com/alphagov/notifications-go-client"
+)
+
+// Delays of the delivery of a notification, from the time it is created or
+// scheduled for, when not set in the Configuration.
+const (
+	DefaultSendingDelay  = time.Second
+	DefaultDeliveryDelay = 10 * time.Second
+)
+
+// smokeTestRecipients documented by GOV.UK Notify, keyed as by recipientKey.
+// Sends to them are accepted, but the notifications are neither stored nor
+// delivered.
+var smokeTestRecipients = map[string]bool{
+	"simulate-delivered@notifications.service.gov.uk":   true,
+	"simulate-delivered-2@notifications.service.gov.uk": true,
+	"simulate-delivered-3@notifications.service.gov.uk": true,
+	"447700900000": true,
+	"447700900111": true,
+	"447700900222": true,
+}
+
+// simulatedOutcomes of the simulated recipients documented by GOV.UK Notify,
+// keyed as by recipientKey. They only apply to notifications sent with a test
+// key.
+var simulatedOutcomes = map[string]notify.Status{
+	"temp-fail@simulator.notify": notify.StatusTemporaryFailure,
+	"perm-fail@simulator.notify": notify.StatusPermanentFailure,
+	"447700900002":               notify.StatusPermanentFailure,
+	"447700900003":               notify.StatusTemporaryFailure,
+}
+
+// SetOutcome sets the final status of the notifications sent to the recipient
+// from now on, overriding the outcome of the simulated recipients. The
+// recipient is an email address, a phone number or, for letters, the postcode.
+// Notifications are delivered when no outcome is set.
+func (s *Server) SetOutcome(recipient string, status notify.Status) error {
+	if !status.IsFinal() || status == notify.StatusFailed {
+		return fmt.Errorf("outcome: %s is not a final status", status)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.outcomes[recipientKey(recipient)] = status
+
+	return nil
+}
+
+// outcome of a notification sent to the recipient with an API key of the type.
+// The caller must hold s.mu.
+func (s *Server) outcome(templateType notify.TemplateType, keyType KeyType, recipient string) notify.Status {
+	key := recipientKey(recipient)
+
+	status, ok := s.outcomes[key]
+	if !ok && keyType == KeyTypeTest {
+		status, ok = simulatedOutcomes[key]
+	}
+
+	if !ok {
+		status = notify.StatusDelivered
+	}
+
+	if templateType == notify.TemplateTypeLetter && (status == notify.StatusDelivered || status == notify.StatusSent) {
+		status = notify.StatusReceived
+	}
+
+	return status
+}
+
+// recipientKey normalises the email address, phone number or postcode so that
+// it can be compared with another.
+func recipientKey(recipient string) string {
+	recipient = strings.TrimSpace(recipient)
+
+	switch {
+	case strings.Contains(recipient, "@"):
+		return strings.ToLower(recipient)
+	case validatePhoneNumber(recipient) == "":
+		return normalisePhoneNumber(recipient)
+	default:
+		return strings.ToUpper(strings.Replace(recipient, " ", "", -1))
+	}
+}
+
+// recipient of the notification, as matched by the outcome rules.
+func (n *notification) recipient() string {
+	switch n.templateType {
+	case notify.TemplateTypeEmail:
+		return n.emailAddress
+	case notify.TemplateTypeSms:
+		return n.phoneNumber
+	}
+
+	if len(n.address) == 0 {
+		return ""
+	}
+
+	return n.address[len(n.address)-1]
+}
+
+// update the status of every notification to the current time, queueing the
+// delivery receipts of those reaching their outcome. The caller must hold s.mu.
+func (s *Server) update() {
+	now := s.now()
+
+	for _, n := range s.notifications {
+		if s.advance(n, now) {
+			s.receipt(n)
+		}
+	}
+}
+
+// advance the notification to its status at the time passed, reporting
+// whether the status changed.
+//
+// Emails and text messages are sending after the SendingDelay, then reach
+// their outcome after the DeliveryDelay. Letters are accepted, then received.
+func (s *Server) advance(n *notification, now time.Time) bool {
+	if n.status.IsFinal() {
+		return false
+	}
+
+	start := n.createdAt
+	if n.scheduledAt != nil && n.scheduledAt.After(start) {
+		start = *n.scheduledAt
+	}
+
+	sending := start.Add(s.configuration.SendingDelay)
+	completed := start.Add(s.configuration.DeliveryDelay)
+
+	var status notify.Status
+
+	switch {
+	case !now.Before(completed):
+		status = n.outcome
+	case !now.Before(sending) && n.templateType == notify.TemplateTypeLetter:
+		status = notify.StatusAccepted
+	case !now.Before(sending):
+		status = notify.StatusSending
+	default:
+		return false
+	}
+
+	if status == n.status {
+		return false
+	}
+
+	if n.sentAt == nil {
+		n.sentAt = &sending
+	}
+
+	if status.IsFinal() {
+		n.completedAt = &completed
+	}
+
+	n.status = status
+
+	return true
+}
@@ -0,0 +1,176 @@
+package notifytest
+
+import (
+	"time"
+
+	notify "github.com/alphagov/notifications-go-client"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Lifecycle", func() {
+	var (
+		server *Server
+		client *notify.Client
+		clock  *Clock
+	)
+
+	BeforeEach(func() {
+		clock = NewClock(time.Now())
+		server, client = newServer(Configuration{Clock: clock})
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	status := func(id string) notify.Status {
+		n, _ := server.Notification(id)
+		return n.Status
+	}
+
+	It("should deliver an email", func() {
+		res, _ := client.SendEmail("betty@example.com", emailTemplateID, map[string]interface{}{"name": "Betty", "ref": "A1"}, "")
+
+		Expect(status(res.ID)).To(Equal(notify.StatusCreated))
+
+		clock.Advance(DefaultSendingDelay)
+
+		n, err := client.GetNotification(res.ID)
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(n.Status).To(Equal(notify.StatusSending))
+		Expect(n.SentAt).NotTo(BeNil())
+		Expect(n.CompletedAt).To(BeNil())
+
+		clock.Advance(DefaultDeliveryDelay - DefaultSendingDelay)
+
+		n, err = client.GetNotification(res.ID)
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(n.Status).To(Equal(notify.StatusDelivered))
+		Expect(n.CompletedAt).NotTo(BeNil())
+		Expect(n.CompletedAt.Sub(n.CreatedAt)).To(Equal(DefaultDeliveryDelay))
+	})
+
+	It("should receive a letter", func() {
+		address := notify.LetterAddress{AddressLine1: "Betty Smith", AddressLine2: "1 Example Street", AddressLine3: "SW1A 1AA"}
+		res, _ := client.SendLetter(address, letterTemplateID, nil, "", "")
+
+		clock.Advance(DefaultSendingDelay)
+		Expect(status(res.ID)).To(Equal(notify.StatusAccepted))
+
+		clock.Advance(DefaultDeliveryDelay)
+		Expect(status(res.ID)).To(Equal(notify.StatusReceived))
+	})
+
+	It("should start delivering a scheduled notification at the time it is scheduled for", func() {
+		res, err := client.SendSms("07700900123", smsTemplateID, map[string]interface{}{"code": 1}, "",
+			notify.WithScheduledFor(clock.Now().Add(time.Hour)))
+
+		Expect(err).ShouldNot(HaveOccurred())
+
+		clock.Advance(DefaultDeliveryDelay)
+		Expect(status(res.ID)).To(Equal(notify.StatusCreated))
+
+		clock.Advance(time.Hour)
+		Expect(status(res.ID)).To(Equal(notify.StatusDelivered))
+	})
+
+	// send the email or text message to the recipient, returning its ID.
+	send := func(recipient string) string {
+		if recipient[0] == '0' {
+			sms, err := client.SendSms(recipient, smsTemplateID, map[string]interface{}{"code": 1}, "")
+			Expect(err).ShouldNot(HaveOccurred())
+			return sms.ID
+		}
+
+		email, err := client.SendEmail(recipient, emailTemplateID, map[string]interface{}{"name": "Betty", "ref": "A1"}, "")
+		Expect(err).ShouldNot(HaveOccurred())
+		return email.ID
+	}
+
+	DescribeTable("should accept a send to the smoke test recipients without storing it",
+		func(recipient string) {
+			id := send(recipient)
+
+			Expect(id).NotTo(BeEmpty())
+			Expect(server.Notifications()).To(BeEmpty())
+
+			_, err := client.GetNotification(id)
+			Expect(err).Should(HaveOccurred())
+		},
+		Entry("simulate-delivered", "simulate-delivered@notifications.service.gov.uk"),
+		Entry("simulate-delivered-2", "simulate-delivered-2@notifications.service.gov.uk"),
+		Entry("simulate-delivered-3", "Simulate-Delivered-3@notifications.service.gov.uk"),
+		Entry("07700900000", "07700900000"),
+		Entry("07700900111", "07700 900 111"),
+		Entry("07700900222", "07700900222"),
+	)
+
+	DescribeTable("should give the simulated recipients their documented outcome with a test key",
+		func(recipient string, expected notify.Status) {
+			client = newClient(server, server.AddAPIKey("test", KeyTypeTest))
+			id := send(recipient)
+
+			clock.Advance(DefaultDeliveryDelay)
+			Expect(status(id)).To(Equal(expected))
+		},
+		Entry("temp-fail", "temp-fail@simulator.notify", notify.StatusTemporaryFailure),
+		Entry("perm-fail", "perm-fail@simulator.notify", notify.StatusPermanentFailure),
+		Entry("07700900002", "07700900002", notify.StatusPermanentFailure),
+		Entry("07700900003", "07700900003", notify.StatusTemporaryFailure),
+	)
+
+	It("should deliver to the simulated recipients with a live or team key", func() {
+		server.Close()
+		server, client = newServer(Configuration{Clock: clock, AllowList: []string{"perm-fail@simulator.notify"}})
+
+		live := send("07700900002")
+
+		client = newClient(server, server.AddAPIKey("team", KeyTypeTeam))
+		team := send("perm-fail@simulator.notify")
+
+		clock.Advance(DefaultDeliveryDelay)
+
+		Expect(status(live)).To(Equal(notify.StatusDelivered))
+		Expect(status(team)).To(Equal(notify.StatusDelivered))
+	})
+
+	It("should give a recipient the outcome set", func() {
+		Expect(server.SetOutcome("+44 7700 900123", notify.StatusTechnicalFailure)).To(Succeed())
+		Expect(server.SetOutcome("sw1a1aa", notify.StatusPermanentFailure)).To(Succeed())
+
+		sms, _ := client.SendSms("07700900123", smsTemplateID, map[string]interface{}{"code": 1}, "")
+		address := notify.LetterAddress{AddressLine1: "Betty Smith", AddressLine2: "1 Example Street", AddressLine3: "SW1A 1AA"}
+		letter, _ := client.SendLetter(address, letterTemplateID, nil, "", "")
+
+		clock.Advance(DefaultDeliveryDelay)
+
+		Expect(status(sms.ID)).To(Equal(notify.StatusTechnicalFailure))
+		Expect(status(letter.ID)).To(Equal(notify.StatusPermanentFailure))
+
+		list, err := client.ListNotifications(notify.Filters{Status: []notify.Status{notify.StatusFailed}})
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(list.Notifications).To(HaveLen(2))
+	})
+
+	It("should stop listening to the clock once closed", func() {
+		other, _ := newServer(Configuration{Clock: clock})
+		Expect(clock.listeners).To(HaveLen(2))
+
+		other.Close()
+		Expect(clock.listeners).To(HaveLen(1))
+
+		server.Close()
+		Expect(clock.listeners).To(BeEmpty())
+	})
+
+	It("should only set a final status as an outcome", func() {
+		Expect(server.SetOutcome("betty@example.com", notify.StatusSending)).NotTo(Succeed())
+		Expect(server.SetOutcome("betty@example.com", notify.StatusFailed)).NotTo(Succeed())
+	})
+})
@@ -0,0 +1,106 @@
+package notifytest
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Limits applied by GOV.UK Notify, used in the errors of the injected faults
+// when the limits are not set in the Configuration.
+const (
+	DefaultRateLimit         = 3000
+	DefaultRateLimitInterval = time.Minute
+	DefaultDailyLimit        = 250000
+	TrialModeDailyLimit      = 50
+)
+
+// limit the sends with the API key to the rate limit of the key and to the
+// daily limit of the service, when they are set in the Configuration.
+func (s *Server) limit(key *apiKey) *requestError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+
+	if s.configuration.RateLimit > 0 {
+		since := now.Add(-s.rateLimitInterval())
+
+		requests := s.requests[key][:0]
+		for _, t := range s.requests[key] {
+			if t.After(since) {
+				requests = append(requests, t)
+			}
+		}
+
+		if len(requests) >= s.configuration.RateLimit {
+			s.requests[key] = requests
+			return s.rateLimitError(key.keyType)
+		}
+
+		s.requests[key] = append(requests, now)
+	}
+
+	if s.configuration.DailyLimit > 0 && key.keyType != KeyTypeTest {
+		y, m, d := now.In(london()).Date()
+
+		sent := 0
+		for _, n := range s.notifications {
+			ny, nm, nd := n.createdAt.In(london()).Date()
+			if n.keyType != KeyTypeTest && ny == y && nm == m && nd == d {
+				sent++
+			}
+		}
+
+		if sent >= s.configuration.DailyLimit {
+			return s.tooManyRequestsError()
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) rateLimitInterval() time.Duration {
+	if s.configuration.RateLimitInterval > 0 {
+		return s.configuration.RateLimitInterval
+	}
+
+	return DefaultRateLimitInterval
+}
+
+func (s *Server) rateLimitError(keyType KeyType) *requestError {
+	limit := s.configuration.RateLimit
+	if limit <= 0 {
+		limit = DefaultRateLimit
+	}
+
+	return &requestError{http.StatusTooManyRequests, "RateLimitError",
+		fmt.Sprintf("Exceeded rate limit for key type %s of %d requests per %d seconds",
+			strings.ToUpper(string(keyType)), limit, int(s.rateLimitInterval().Seconds()))}
+}
+
+func (s *Server) tooManyRequestsError() *requestError {
+	limit := s.configuration.DailyLimit
+	switch {
+	case limit > 0:
+	case s.configuration.TrialMode:
+		limit = TrialModeDailyLimit
+	default:
+		limit = DefaultDailyLimit
+	}
+
+	return &requestError{http.StatusTooManyRequests, "TooManyRequestsError",
+		fmt.Sprintf("Exceeded send limits (%d) for today", limit)}
+}
+
+// london is the time zone of GOV.UK Notify, falling back to UTC when the time
+// zone database is not available.
+func london() *time.Location {
+	loc, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
+}
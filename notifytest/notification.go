@@ -0,0 +1,334 @@
+package notifytest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	notify "github.com/alphagov/notifications-go-client"
+)
+
+// timeFormat of the times returned by GOV.UK Notify.
+const timeFormat = "2006-01-02T15:04:05.000000Z"
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// notification sent to the fake server.
+type notification struct {
+	id              string
+	reference       string
+	templateType    notify.TemplateType
+	keyType         KeyType
+	status          notify.Status
+	template        Template
+	emailAddress    string
+	phoneNumber     string
+	address         []string
+	postage         notify.Postage
+	subject         string
+	body            string
+	personalisation map[string]interface{}
+	scheduledFor    string
+	scheduledAt     *time.Time
+	outcome         notify.Status
+	createdAt       time.Time
+	sentAt          *time.Time
+	completedAt     *time.Time
+
+	oneClickUnsubscribeURL string
+}
+
+// notificationJSON is a notification as it is returned by GOV.UK Notify, with
+// null for the fields that do not apply.
+type notificationJSON struct {
+	ID                string                 `json:"id"`
+	Reference         *string                `json:"reference"`
+	EmailAddress      *string                `json:"email_address"`
+	PhoneNumber       *string                `json:"phone_number"`
+	Line1             *string                `json:"line_1"`
+	Line2             *string                `json:"line_2"`
+	Line3             *string                `json:"line_3"`
+	Line4             *string                `json:"line_4"`
+	Line5             *string                `json:"line_5"`
+	Line6             *string                `json:"line_6"`
+	Line7             *string                `json:"line_7"`
+	Postcode          *string                `json:"postcode"`
+	Postage           *notify.Postage        `json:"postage"`
+	Type              notify.TemplateType    `json:"type"`
+	Status            notify.Status          `json:"status"`
+	Template          templateJSON           `json:"template"`
+	Body              string                 `json:"body"`
+	Subject           *string                `json:"subject"`
+	CreatedAt         string                 `json:"created_at"`
+	CreatedByName     *string                `json:"created_by_name"`
+	SentAt            *string                `json:"sent_at"`
+	CompletedAt       *string                `json:"completed_at"`
+	ScheduledFor      *string                `json:"scheduled_for"`
+	EstimatedDelivery *string                `json:"estimated_delivery"`
+	IsCostDataReady   bool                   `json:"is_cost_data_ready"`
+	CostInPounds      *float64               `json:"cost_in_pounds"`
+	CostDetails       map[string]interface{} `json:"cost_details"`
+}
+
+type templateJSON struct {
+	ID      string `json:"id"`
+	Version int64  `json:"version"`
+	URI     string `json:"uri"`
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+
+	return &s
+}
+
+func optionalTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+
+	return optional(t.UTC().Format(timeFormat))
+}
+
+func (s *Server) notificationJSON(n *notification) notificationJSON {
+	j := notificationJSON{
+		ID:           n.id,
+		Reference:    optional(n.reference),
+		EmailAddress: optional(n.emailAddress),
+		PhoneNumber:  optional(n.phoneNumber),
+		Type:         n.templateType,
+		Status:       n.status,
+		Template: templateJSON{
+			ID:      n.template.ID,
+			Version: n.template.Version,
+			URI:     s.templateURI(n.template.ID),
+		},
+		Body:         n.body,
+		Subject:      optional(n.subject),
+		CreatedAt:    n.createdAt.UTC().Format(timeFormat),
+		SentAt:       optionalTime(n.sentAt),
+		CompletedAt:  optionalTime(n.completedAt),
+		ScheduledFor: optional(n.scheduledFor),
+		CostDetails:  map[string]interface{}{},
+	}
+
+	if n.templateType == notify.TemplateTypeLetter {
+		lines := []**string{&j.Line1, &j.Line2, &j.Line3, &j.Line4, &j.Line5, &j.Line6, &j.Line7}
+		for i, line := range n.address {
+			*lines[i] = optional(line)
+		}
+
+		if len(n.address) > 0 {
+			j.Postcode = optional(n.address[len(n.address)-1])
+		}
+
+		j.Postage = &n.postage
+	}
+
+	return j
+}
+
+// Notifications returns every notification sent to the server, the most
+// recent first, as they would be returned to the client.
+func (s *Server) Notifications() []notify.Notification {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.update()
+
+	notifications := make([]notify.Notification, 0, len(s.notifications))
+	for i := len(s.notifications) - 1; i >= 0; i-- {
+		notifications = append(notifications, s.toNotification(s.notifications[i]))
+	}
+
+	return notifications
+}
+
+// Notification returns the notification with the ID, if it was sent to the
+// server.
+func (s *Server) Notification(id string) (notify.Notification, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.update()
+
+	n := s.lookup(id)
+	if n == nil {
+		return notify.Notification{}, false
+	}
+
+	return s.toNotification(n), true
+}
+
+// toNotification decodes the notification from its JSON, exactly as the
+// client would.
+func (s *Server) toNotification(n *notification) notify.Notification {
+	var notification notify.Notification
+
+	b, _ := json.Marshal(s.notificationJSON(n))
+	json.Unmarshal(b, &notification)
+
+	return notification
+}
+
+// lookup the notification with the ID. The caller must hold s.mu.
+func (s *Server) lookup(id string) *notification {
+	for _, n := range s.notifications {
+		if strings.EqualFold(n.id, id) {
+			return n
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) getNotification(w http.ResponseWriter, r *http.Request, key *apiKey) {
+	id := strings.TrimPrefix(strings.TrimSuffix(r.URL.Path, "/"), "/v2/notifications/")
+
+	if !uuidPattern.MatchString(id) {
+		writeError(w, http.StatusBadRequest, "ValidationError", "notification_id is not a valid UUID")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.update()
+
+	n := s.lookup(id)
+	if n == nil {
+		writeError(w, http.StatusNotFound, "NoResultFound", "No result found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.notificationJSON(n))
+}
+
+func (s *Server) listNotifications(w http.ResponseWriter, r *http.Request, key *apiKey) {
+	query := r.URL.Query()
+
+	templateType := notify.TemplateType(query.Get("template_type"))
+	if templateType != "" && !templateType.IsValid() {
+		writeError(w, http.StatusBadRequest, "ValidationError",
+			fmt.Sprintf("template_type %s is not one of [sms, email, letter]", templateType))
+		return
+	}
+
+	statuses := map[notify.Status]bool{}
+	for _, v := range query["status"] {
+		status := notify.Status(v)
+		if !status.IsValid() {
+			writeError(w, http.StatusBadRequest, "ValidationError",
+				fmt.Sprintf("status %s is not one of [%s]", status, strings.Join(validStatuses, ", ")))
+			return
+		}
+
+		if status == notify.StatusFailed {
+			statuses[notify.StatusPermanentFailure] = true
+			statuses[notify.StatusTemporaryFailure] = true
+			statuses[notify.StatusTechnicalFailure] = true
+			continue
+		}
+
+		statuses[status] = true
+	}
+
+	reference := query.Get("reference")
+	olderThan := query.Get("older_than")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.update()
+
+	// Notifications are listed from the most recent, starting after the one
+	// passed as older_than when set, and only when sent with the same type of
+	// key.
+	older := olderThan == ""
+	page := []notificationJSON{}
+	more := false
+
+	for i := len(s.notifications) - 1; i >= 0; i-- {
+		n := s.notifications[i]
+
+		if !older {
+			older = strings.EqualFold(n.id, olderThan)
+			continue
+		}
+
+		if n.keyType != key.keyType {
+			continue
+		}
+
+		if templateType != "" && n.templateType != templateType {
+			continue
+		}
+
+		if len(statuses) > 0 && !statuses[n.status] {
+			continue
+		}
+
+		if reference != "" && n.reference != reference {
+			continue
+		}
+
+		if len(page) == s.configuration.PageSize {
+			more = true
+			break
+		}
+
+		page = append(page, s.notificationJSON(n))
+	}
+
+	links := map[string]string{
+		"current": s.listURL(query),
+	}
+
+	if more {
+		next := url.Values{}
+		for k, v := range query {
+			next[k] = v
+		}
+		next.Set("older_than", page[len(page)-1].ID)
+
+		links["next"] = s.listURL(next)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"notifications": page,
+		"links":         links,
+	})
+}
+
+func (s *Server) listURL(query url.Values) string {
+	u := s.URL + "/v2/notifications"
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	return u
+}
+
+var validStatuses = []string{
+	string(notify.StatusCancelled),
+	string(notify.StatusCreated),
+	string(notify.StatusSending),
+	string(notify.StatusSent),
+	string(notify.StatusDelivered),
+	string(notify.StatusPending),
+	string(notify.StatusFailed),
+	string(notify.StatusTechnicalFailure),
+	string(notify.StatusTemporaryFailure),
+	string(notify.StatusPermanentFailure),
+	string(notify.StatusPendingVirusCheck),
+	string(notify.StatusValidationFailed),
+	string(notify.StatusVirusScanFailed),
+	string(notify.StatusAccepted),
+	string(notify.StatusReceived),
+}
@@ -0,0 +1,113 @@
+package notifytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	notify "github.com/alphagov/notifications-go-client"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Notification", func() {
+	var (
+		server *Server
+		client *notify.Client
+	)
+
+	BeforeEach(func() {
+		server, client = newServer(Configuration{PageSize: 2})
+
+		for i := 0; i < 3; i++ {
+			client.SendSms("07700900123", smsTemplateID, map[string]interface{}{"code": i}, fmt.Sprintf("ref-%d", i))
+		}
+
+		client.SendEmail("betty@example.com", emailTemplateID, map[string]interface{}{"name": "Betty", "ref": "A1"}, "ref-1")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should get a notification", func() {
+		sent := server.Notifications()[0]
+
+		n, err := client.GetNotification(sent.ID)
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(n.ID).To(Equal(sent.ID))
+		Expect(n.Email).To(Equal("betty@example.com"))
+		Expect(n.Subject).To(Equal("Hello Betty"))
+		Expect(n.Template.URI).To(ContainSubstring("/templates/" + emailTemplateID))
+		Expect(n.SentAt).To(BeNil())
+	})
+
+	It("should not find an unknown notification", func() {
+		_, err := client.GetNotification("00000000-0000-4000-8000-000000000000")
+
+		var e *notify.NotFoundError
+		Expect(errors.As(err, &e)).To(BeTrue())
+	})
+
+	It("should reject an invalid notification ID", func() {
+		_, err := client.GetNotification("n0t1-1234567890")
+
+		var e *notify.ValidationError
+		Expect(errors.As(err, &e)).To(BeTrue())
+	})
+
+	It("should list the most recent notifications first, a page at a time", func() {
+		list, err := client.ListNotifications(notify.Filters{})
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(list.Notifications).To(HaveLen(2))
+		Expect(list.Notifications[0].Type).To(Equal(notify.TemplateTypeEmail))
+		Expect(list.Notifications[1].Reference).To(Equal("ref-2"))
+		Expect(list.Links.Next).NotTo(BeEmpty())
+
+		err = list.Next()
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(list.Notifications).To(HaveLen(2))
+		Expect(list.Notifications[0].Reference).To(Equal("ref-1"))
+		Expect(list.Notifications[1].Reference).To(Equal("ref-0"))
+		Expect(list.Links.Next).To(BeEmpty())
+	})
+
+	It("should list notifications older than another", func() {
+		all := server.Notifications()
+
+		list, err := client.ListNotifications(notify.Filters{OlderThan: all[1].ID})
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(list.Notifications).To(HaveLen(2))
+		Expect(list.Notifications[0].ID).To(Equal(all[2].ID))
+	})
+
+	It("should filter notifications", func() {
+		list, err := client.ListNotifications(notify.Filters{TemplateType: notify.TemplateTypeSms, Reference: "ref-1"})
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(list.Notifications).To(HaveLen(1))
+		Expect(list.Notifications[0].Type).To(Equal(notify.TemplateTypeSms))
+
+		list, err = client.ListNotifications(notify.Filters{Status: []notify.Status{notify.StatusDelivered}})
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(list.Notifications).To(BeEmpty())
+	})
+
+	It("should iterate over every notification", func() {
+		it := client.IterateNotifications(context.Background(), notify.Filters{})
+
+		count := 0
+		for it.Next() {
+			count++
+		}
+
+		Expect(it.Err()).ShouldNot(HaveOccurred())
+		Expect(count).To(Equal(4))
+	})
+})
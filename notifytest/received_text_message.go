@@ -0,0 +1,138 @@
+package notifytest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	notify "github.com/alphagov/notifications-go-client"
+)
+
+// PathReceiveTextMessage is the endpoint of the fake server receiving a text
+// message from a user, as JSON with the user_number and content. It does not
+// require a token.
+const PathReceiveTextMessage = "/notifytest/received-text-messages"
+
+// receivedTextMessage sent by a user to the inbound number of the service.
+type receivedTextMessage struct {
+	id           string
+	userNumber   string
+	notifyNumber string
+	content      string
+	createdAt    time.Time
+}
+
+type receivedTextMessageJSON struct {
+	ID           string `json:"id"`
+	UserNumber   string `json:"user_number"`
+	NotifyNumber string `json:"notify_number"`
+	ServiceID    string `json:"service_id"`
+	Content      string `json:"content"`
+	CreatedAt    string `json:"created_at"`
+}
+
+func (s *Server) receivedTextMessageJSON(m *receivedTextMessage) receivedTextMessageJSON {
+	return receivedTextMessageJSON{
+		ID:           m.id,
+		UserNumber:   m.userNumber,
+		NotifyNumber: m.notifyNumber,
+		ServiceID:    s.configuration.ServiceID,
+		Content:      m.content,
+		CreatedAt:    m.createdAt.UTC().Format(timeFormat),
+	}
+}
+
+// ReceiveTextMessage from the user to the inbound number of the service. It is
+// listed as a received text message and posted to the ReceivedTextMessages
+// callback.
+func (s *Server) ReceiveTextMessage(userNumber, content string) notify.ReceivedTextMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m := &receivedTextMessage{
+		id:           newID(),
+		userNumber:   normalisePhoneNumber(userNumber),
+		notifyNumber: s.configuration.InboundNumber,
+		content:      content,
+		createdAt:    s.now(),
+	}
+
+	s.receivedTextMessages = append(s.receivedTextMessages, m)
+
+	s.enqueue(s.configuration.ReceivedTextMessages, inboundSms{
+		ID:                m.id,
+		SourceNumber:      m.userNumber,
+		DestinationNumber: m.notifyNumber,
+		Message:           m.content,
+		DateReceived:      m.createdAt.UTC().Format(timeFormat),
+	})
+
+	var received notify.ReceivedTextMessage
+
+	b, _ := json.Marshal(s.receivedTextMessageJSON(m))
+	json.Unmarshal(b, &received)
+
+	return received
+}
+
+func (s *Server) receiveTextMessage(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserNumber string `json:"user_number"`
+		Content    string `json:"content"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "BadRequestError", "Invalid JSON supplied in POST data")
+		return
+	}
+
+	if validatePhoneNumber(req.UserNumber) != "" {
+		writeError(w, http.StatusBadRequest, "ValidationError", "user_number is not a valid phone number")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, s.ReceiveTextMessage(req.UserNumber, req.Content))
+}
+
+func (s *Server) listReceivedTextMessages(w http.ResponseWriter, r *http.Request, key *apiKey) {
+	query := r.URL.Query()
+	olderThan := query.Get("older_than")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	older := olderThan == ""
+	page := []receivedTextMessageJSON{}
+	more := false
+
+	for i := len(s.receivedTextMessages) - 1; i >= 0; i-- {
+		m := s.receivedTextMessages[i]
+
+		if !older {
+			older = strings.EqualFold(m.id, olderThan)
+			continue
+		}
+
+		if len(page) == s.configuration.PageSize {
+			more = true
+			break
+		}
+
+		page = append(page, s.receivedTextMessageJSON(m))
+	}
+
+	links := map[string]string{
+		"current": s.URL + notify.PathReceivedTextMessageList,
+	}
+
+	if more {
+		links["next"] = s.URL + notify.PathReceivedTextMessageList + "?" + url.Values{"older_than": {page[len(page)-1].ID}}.Encode()
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"received_text_messages": page,
+		"links":                  links,
+	})
+}
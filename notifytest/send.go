@@ -0,0 +1,338 @@
+package notifytest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	notify "github.com/alphagov/notifications-go-client"
+)
+
+// PrecompiledTemplateID is the ID of the template used for precompiled
+// letters.
+const PrecompiledTemplateID = "0a4d8fc4-2ddf-4b5e-a2e0-a9b7e9a9e4e6"
+
+var emailPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+
+// sendRequest is the payload of every send endpoint. The Content is only set
+// for precompiled letters.
+type sendRequest struct {
+	notify.Payload
+	Content string `json:"content"`
+}
+
+type requestError struct {
+	statusCode int
+	name       string
+	message    string
+}
+
+func validationError(format string, a ...interface{}) *requestError {
+	return &requestError{http.StatusBadRequest, "ValidationError", fmt.Sprintf(format, a...)}
+}
+
+func badRequestError(format string, a ...interface{}) *requestError {
+	return &requestError{http.StatusBadRequest, "BadRequestError", fmt.Sprintf(format, a...)}
+}
+
+func (s *Server) sendEmail(w http.ResponseWriter, r *http.Request, key *apiKey) {
+	s.send(w, r, key, notify.TemplateTypeEmail, func(req *sendRequest, n *notification) *requestError {
+		if req.EmailAddress == "" {
+			return validationError("email_address is a required property")
+		}
+
+		if !emailPattern.MatchString(req.EmailAddress) {
+			return validationError("email_address Not a valid email address")
+		}
+
+		n.emailAddress = req.EmailAddress
+		n.oneClickUnsubscribeURL = req.OneClickUnsubscribeURL
+
+		return nil
+	}, func(n *notification) interface{} {
+		return map[string]interface{}{
+			"body":                      n.body,
+			"subject":                   n.subject,
+			"from_email":                s.configuration.FromEmail,
+			"one_click_unsubscribe_url": optional(n.oneClickUnsubscribeURL),
+		}
+	})
+}
+
+func (s *Server) sendSms(w http.ResponseWriter, r *http.Request, key *apiKey) {
+	s.send(w, r, key, notify.TemplateTypeSms, func(req *sendRequest, n *notification) *requestError {
+		if req.PhoneNumber == "" {
+			return validationError("phone_number is a required property")
+		}
+
+		if err := validatePhoneNumber(req.PhoneNumber); err != "" {
+			return validationError("phone_number %s", err)
+		}
+
+		n.phoneNumber = req.PhoneNumber
+
+		return nil
+	}, func(n *notification) interface{} {
+		return map[string]interface{}{
+			"body":        n.body,
+			"from_number": s.configuration.FromNumber,
+		}
+	})
+}
+
+func (s *Server) sendLetter(w http.ResponseWriter, r *http.Request, key *apiKey) {
+	s.send(w, r, key, notify.TemplateTypeLetter, func(req *sendRequest, n *notification) *requestError {
+		n.postage = req.Postage
+		if n.postage == "" {
+			n.postage = notify.PostageSecond
+		}
+
+		switch n.postage {
+		case notify.PostageFirst, notify.PostageSecond, notify.PostageEconomy,
+			notify.PostageEurope, notify.PostageRestOfWorld:
+		default:
+			return validationError("postage invalid. It must be either first, second, economy, europe or rest-of-world.")
+		}
+
+		if req.Content != "" {
+			return nil
+		}
+
+		if req.Personalisation == nil {
+			return validationError("personalisation is a required property")
+		}
+
+		for i := 1; i <= 7; i++ {
+			line, _ := req.Personalisation[fmt.Sprintf("address_line_%d", i)].(string)
+			if strings.TrimSpace(line) != "" {
+				n.address = append(n.address, line)
+			}
+		}
+
+		if len(n.address) < 3 {
+			return validationError("Address must be at least 3 lines")
+		}
+
+		return nil
+	}, func(n *notification) interface{} {
+		return map[string]interface{}{
+			"body":    n.body,
+			"subject": n.subject,
+		}
+	})
+}
+
+// send validates the request, renders the template, checks the API key is
+// permitted to send it and stores the new notification. Precompiled letters,
+// sent with Content, skip the template.
+func (s *Server) send(w http.ResponseWriter, r *http.Request, key *apiKey, templateType notify.TemplateType,
+	recipient func(*sendRequest, *notification) *requestError, content func(*notification) interface{}) {
+	if err := s.limit(key); err != nil {
+		err.write(w)
+		return
+	}
+
+	var req sendRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "BadRequestError", "Invalid JSON supplied in POST data")
+		return
+	}
+
+	n := &notification{
+		id:              newID(),
+		reference:       req.Reference,
+		templateType:    templateType,
+		keyType:         key.keyType,
+		status:          notify.StatusCreated,
+		personalisation: req.Personalisation,
+	}
+
+	precompiled := templateType == notify.TemplateTypeLetter && req.Content != ""
+
+	if err := recipient(&req, n); err != nil {
+		err.write(w)
+		return
+	}
+
+	var err *requestError
+	if precompiled {
+		err = s.precompile(&req, n)
+	} else {
+		err = s.prepare(&req, n)
+	}
+
+	if err == nil {
+		err = s.permit(key, n)
+	}
+
+	if err != nil {
+		err.write(w)
+		return
+	}
+
+	s.mu.Lock()
+	n.createdAt = s.now()
+	n.outcome = s.outcome(templateType, key.keyType, n.recipient())
+
+	// Notifications sent to the smoke test recipients are only acknowledged.
+	if !smokeTestRecipients[recipientKey(n.recipient())] {
+		s.notifications = append(s.notifications, n)
+	}
+	s.mu.Unlock()
+
+	if precompiled {
+		writeJSON(w, http.StatusCreated, map[string]interface{}{
+			"id":        n.id,
+			"reference": n.reference,
+			"postage":   n.postage,
+		})
+		return
+	}
+
+	response := map[string]interface{}{
+		"id":        n.id,
+		"reference": optional(n.reference),
+		"content":   content(n),
+		"uri":       fmt.Sprintf("%s/v2/notifications/%s", s.URL, n.id),
+		"template": templateJSON{
+			ID:      n.template.ID,
+			Version: n.template.Version,
+			URI:     s.templateURI(n.template.ID),
+		},
+		"scheduled_for": optional(n.scheduledFor),
+	}
+
+	if templateType == notify.TemplateTypeLetter {
+		response["postage"] = n.postage
+	}
+
+	writeJSON(w, http.StatusCreated, response)
+}
+
+// prepare the notification from its template.
+func (s *Server) prepare(req *sendRequest, n *notification) *requestError {
+	if req.TemplateID == "" {
+		return validationError("template_id is a required property")
+	}
+
+	if !uuidPattern.MatchString(req.TemplateID) {
+		return validationError("template_id is not a valid UUID")
+	}
+
+	t, ok := s.template(req.TemplateID)
+	if !ok {
+		return badRequestError("Template not found")
+	}
+
+	if t.Type != n.templateType {
+		return badRequestError("%s template is not suitable for %s notification", t.Type, n.templateType)
+	}
+
+	body, missing := render(t.Body, req.Personalisation)
+	subject, missingFromSubject := render(t.Subject, req.Personalisation)
+
+	missing = append(missingFromSubject, missing...)
+	if len(missing) > 0 {
+		return badRequestError("Missing personalisation: %s", strings.Join(missing, ", "))
+	}
+
+	if req.ScheduledFor != "" {
+		t, err := s.schedule(req.ScheduledFor)
+		if err != nil {
+			return err
+		}
+
+		n.scheduledFor = req.ScheduledFor
+		n.scheduledAt = &t
+	}
+
+	n.template = t
+	n.body = body
+	n.subject = subject
+
+	return nil
+}
+
+// precompile the letter from its base64 encoded PDF.
+func (s *Server) precompile(req *sendRequest, n *notification) *requestError {
+	if req.Reference == "" {
+		return validationError("reference is a required property")
+	}
+
+	if _, err := base64.StdEncoding.DecodeString(req.Content); err != nil {
+		return badRequestError("Cannot decode letter content (invalid base64 encoding)")
+	}
+
+	n.template = Template{ID: PrecompiledTemplateID, Type: notify.TemplateTypeLetter, Version: 1}
+	n.status = notify.StatusPendingVirusCheck
+
+	return nil
+}
+
+// schedule validates the time a notification is scheduled for, in the
+// Europe/London time zone.
+func (s *Server) schedule(scheduledFor string) (time.Time, *requestError) {
+	t, err := time.ParseInLocation("2006-01-02 15:04", scheduledFor, london())
+	if err != nil {
+		return t, validationError("scheduled_for datetime format is invalid. It must be a valid ISO8601 date time format, https://en.wikipedia.org/wiki/ISO_8601")
+	}
+
+	now := s.now()
+
+	if t.Before(now) {
+		return t, validationError("scheduled_for datetime can not be in the past")
+	}
+
+	if t.After(now.Add(notify.MaxScheduleAhead)) {
+		return t, validationError("scheduled_for datetime can only be 24 hours in the future")
+	}
+
+	return t, nil
+}
+
+// validatePhoneNumber returns why the phone number is not valid, or an empty
+// string when it is.
+func validatePhoneNumber(phoneNumber string) string {
+	digits := normalisePhoneNumber(phoneNumber)
+
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return "Must not contain letters or symbols"
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(digits, "447"):
+		if len(digits) < 12 {
+			return "Not enough digits"
+		}
+		if len(digits) > 12 {
+			return "Too many digits"
+		}
+	case strings.HasPrefix(digits, "44"):
+		return "Not a UK mobile number"
+	case len(digits) < 8:
+		return "Not enough digits"
+	case len(digits) > 15:
+		return "Too many digits"
+	}
+
+	return ""
+}
+
+// normalisePhoneNumber strips the spaces and symbols allowed in a phone number
+// and returns it in international format, such as 447700900123.
+func normalisePhoneNumber(phoneNumber string) string {
+	digits := strings.NewReplacer(" ", "", "-", "", "(", "", ")", "", "+", "").Replace(phoneNumber)
+	digits = strings.TrimPrefix(digits, "00")
+
+	if strings.HasPrefix(digits, "0") {
+		digits = "44" + strings.TrimPrefix(digits, "0")
+	}
+
+	return digits
+}
@@ -0,0 +1,161 @@
+package notifytest
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	notify "github.com/alphagov/notifications-go-client"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Send", func() {
+	var (
+		server *Server
+		client *notify.Client
+	)
+
+	BeforeEach(func() {
+		server, client = newServer(Configuration{FromNumber: "Example"})
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should send an email", func() {
+		res, err := client.SendEmail("betty@example.com", emailTemplateID, map[string]interface{}{"name": "Betty", "ref": "A1"}, "ref-1")
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(res.ID).NotTo(BeEmpty())
+		Expect(res.Reference).To(Equal("ref-1"))
+		Expect(res.Content.Subject).To(Equal("Hello Betty"))
+		Expect(res.Content.Body).To(Equal("Your reference is A1"))
+		Expect(res.Content.FromEmail).To(Equal("test.service@notifications.service.gov.uk"))
+		Expect(res.URI).To(Equal(server.URL + "/v2/notifications/" + res.ID))
+		Expect(res.Template.ID).To(Equal(emailTemplateID))
+		Expect(res.Template.Version).To(Equal(int64(1)))
+
+		n, ok := server.Notification(res.ID)
+
+		Expect(ok).To(BeTrue())
+		Expect(n.Email).To(Equal("betty@example.com"))
+		Expect(n.Type).To(Equal(notify.TemplateTypeEmail))
+		Expect(n.Status).To(Equal(notify.StatusCreated))
+	})
+
+	It("should not schedule a notification earlier in the current minute", func() {
+		t := time.Now().Add(time.Minute)
+		scheduled := t.Truncate(time.Minute).Add(time.Minute)
+
+		server.Close()
+		server, client = newServer(Configuration{Clock: NewClock(scheduled.Add(20 * time.Second))})
+
+		_, err := client.SendSms("07700900123", smsTemplateID, map[string]interface{}{"code": 1}, "",
+			notify.WithScheduledFor(t))
+
+		var e *notify.ValidationError
+		Expect(errors.As(err, &e)).To(BeTrue())
+		Expect(e.Errors[0].Message).To(Equal("scheduled_for datetime can not be in the past"))
+	})
+
+	It("should send a text message", func() {
+		res, err := client.SendSms("07700 900123", smsTemplateID, map[string]interface{}{"code": 1234}, "")
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(res.Content.Body).To(Equal("Your code is 1234"))
+		Expect(res.Content.FromNumber).To(Equal("Example"))
+		Expect(server.Notifications()).To(HaveLen(1))
+		Expect(server.Notifications()[0].Phone).To(Equal("07700 900123"))
+	})
+
+	It("should send a letter", func() {
+		address := notify.LetterAddress{AddressLine1: "Betty Smith", AddressLine2: "1 Example Street", AddressLine3: "SW1A 1AA"}
+
+		res, err := client.SendLetter(address, letterTemplateID, nil, "", notify.PostageFirst)
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(res.Content.Body).To(Equal("Dear Betty Smith"))
+		Expect(res.Postage).To(Equal(notify.PostageFirst))
+
+		n, _ := server.Notification(res.ID)
+
+		Expect(n.Line3).To(Equal("SW1A 1AA"))
+		Expect(n.Postcode).To(Equal("SW1A 1AA"))
+	})
+
+	It("should send a precompiled letter", func() {
+		res, err := client.SendPrecompiledLetter("ref-1", strings.NewReader("%PDF-1.4"), "")
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(res.Reference).To(Equal("ref-1"))
+		Expect(res.Postage).To(Equal(notify.PostageSecond))
+
+		n, _ := server.Notification(res.ID)
+
+		Expect(n.Status).To(Equal(notify.StatusPendingVirusCheck))
+		Expect(n.Template.ID).To(Equal(PrecompiledTemplateID))
+	})
+
+	It("should reject an invalid email address", func() {
+		_, err := client.SendEmail("betty", emailTemplateID, map[string]interface{}{"name": "Betty", "ref": "A1"}, "")
+
+		var e *notify.ValidationError
+		Expect(errors.As(err, &e)).To(BeTrue())
+		Expect(e.Errors[0].Message).To(Equal("email_address Not a valid email address"))
+		Expect(server.Notifications()).To(BeEmpty())
+	})
+
+	It("should reject an invalid phone number", func() {
+		_, err := client.SendSms("07700 9001", smsTemplateID, map[string]interface{}{"code": 1234}, "")
+
+		var e *notify.ValidationError
+		Expect(errors.As(err, &e)).To(BeTrue())
+		Expect(e.Errors[0].Message).To(Equal("phone_number Not enough digits"))
+	})
+
+	It("should reject an unknown template", func() {
+		_, err := client.SendSms("07700900123", "00000000-0000-4000-8000-000000000000", nil, "")
+
+		var e *notify.BadRequestError
+		Expect(errors.As(err, &e)).To(BeTrue())
+		Expect(e.Errors[0].Message).To(Equal("Template not found"))
+	})
+
+	It("should reject a template of another type", func() {
+		_, err := client.SendSms("07700900123", emailTemplateID, nil, "")
+
+		var e *notify.BadRequestError
+		Expect(errors.As(err, &e)).To(BeTrue())
+		Expect(e.Errors[0].Message).To(Equal("email template is not suitable for sms notification"))
+	})
+
+	It("should reject missing personalisation", func() {
+		_, err := client.SendEmail("betty@example.com", emailTemplateID, map[string]interface{}{"Name": "Betty"}, "")
+
+		var e *notify.BadRequestError
+		Expect(errors.As(err, &e)).To(BeTrue())
+		Expect(e.Errors[0].Message).To(Equal("Missing personalisation: ref"))
+	})
+
+	It("should reject a letter with a short address", func() {
+		address := notify.LetterAddress{AddressLine1: "Betty Smith", AddressLine2: "SW1A 1AA"}
+
+		_, err := client.SendLetter(address, letterTemplateID, nil, "", "")
+
+		var e *notify.ValidationError
+		Expect(errors.As(err, &e)).To(BeTrue())
+		Expect(e.Errors[0].Message).To(Equal("Address must be at least 3 lines"))
+	})
+
+	It("should validate phone numbers", func() {
+		Expect(validatePhoneNumber("+44 7700 900123")).To(BeEmpty())
+		Expect(validatePhoneNumber("0044 7700 900123")).To(BeEmpty())
+		Expect(validatePhoneNumber("+1 202 555 0123")).To(BeEmpty())
+		Expect(validatePhoneNumber("07700 90012a")).To(Equal("Must not contain letters or symbols"))
+		Expect(validatePhoneNumber("07700 9001234")).To(Equal("Too many digits"))
+		Expect(validatePhoneNumber("020 7946 0000")).To(Equal("Not a UK mobile number"))
+	})
+})
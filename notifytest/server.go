@@ -0,0 +1,296 @@
+// Package notifytest provides an in-memory fake of the GOV.UK Notify API for
+// testing code that uses the Notifications Go Client.
+//
+// The fake implements the v2 endpoints used to send emails, text messages and
+// letters, to get a notification and to list notifications, and keeps every
+// notification sent in memory. The notifications go through the statuses they
+// would in GOV.UK Notify, driven by a Clock that may be controlled by the test,
+// and their delivery receipts are posted to the callback of the service:
+//
+//	server := notifytest.NewServer(notifytest.Configuration{})
+//	defer server.Close()
+//
+//	server.AddTemplate(notifytest.Template{
+//		ID:   "df10a23e-2c0d-4ea5-87fb-82e520cbf93c",
+//		Type: notify.TemplateTypeEmail,
+//		Body: "Hello ((name))",
+//	})
+//
+//	config, _ := notify.NewConfiguration(server.AddAPIKey("my-key", notifytest.KeyTypeNormal))
+//	config.BaseURL, _ = url.Parse(server.URL)
+package notifytest
+
+import (
+	crand "crypto/rand"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	notify "github.com/alphagov/notifications-go-client"
+)
+
+// Configuration of the fake server. Every field is optional.
+type Configuration struct {
+	// ServiceID that tokens must be issued by, also used in the URIs of
+	// templates. A random one is used when not set.
+	ServiceID string
+	// FromEmail is the address emails are sent from.
+	FromEmail string
+	// FromNumber is the sender of text messages.
+	FromNumber string
+	// PageSize is the number of notifications returned per page, 250 by
+	// default as in GOV.UK Notify.
+	PageSize int
+	// Clock drives the delivery of notifications. The system clock is used
+	// when not set.
+	Clock *Clock
+	// SendingDelay and DeliveryDelay are the time it takes for notifications
+	// to be sending and to reach their outcome, DefaultSendingDelay and
+	// DefaultDeliveryDelay when not set.
+	SendingDelay  time.Duration
+	DeliveryDelay time.Duration
+	// TrialMode restricts the notifications sent with any key other than a
+	// test key to the recipients in the AllowList, and forbids letters.
+	TrialMode bool
+	// AllowList of the email addresses and phone numbers of the team and its
+	// guest list, who notifications can be sent to with a team key or in
+	// trial mode.
+	AllowList []string
+	// RateLimit is the number of sends allowed per RateLimitInterval with an
+	// API key, DefaultRateLimitInterval when not set. Sends are not rate
+	// limited when not set.
+	RateLimit         int
+	RateLimitInterval time.Duration
+	// DailyLimit is the number of notifications the service can send in a
+	// day, not counting those sent with a test key. Sends are not limited
+	// when not set.
+	DailyLimit int
+	// Seed of the probability of injecting faults. A random one is used when
+	// not set.
+	Seed int64
+	// DeliveryReceipts is the callback the receipts of emails and text
+	// messages are posted to, when they reach their outcome.
+	DeliveryReceipts *Callback
+	// ReceivedTextMessages is the callback the text messages received are
+	// posted to.
+	ReceivedTextMessages *Callback
+	// InboundNumber text messages are received on, 07700900500 by default.
+	InboundNumber string
+	// CallbackClient posts the callbacks, with a 10 second timeout by
+	// default.
+	CallbackClient *http.Client
+}
+
+// Server is a fake GOV.UK Notify API listening on a local address. It is safe
+// for concurrent use.
+type Server struct {
+	// URL of the server, to be used as the BaseURL of the client.
+	URL string
+
+	configuration Configuration
+	httpServer    *httptest.Server
+	now           func() time.Time
+	unsubscribe   func()
+
+	mu            sync.Mutex
+	templates     map[string]Template
+	outcomes      map[string]notify.Status
+	apiKeys       []*apiKey
+	requests      map[*apiKey][]time.Time
+	faults        []*Fault
+	rand          *rand.Rand
+	notifications []*notification
+
+	receivedTextMessages []*receivedTextMessage
+
+	queueMu   sync.Mutex
+	queueCond *sync.Cond
+	queue     []callback
+	closed    bool
+	done      chan struct{}
+}
+
+// NewServer starts and returns a new fake server. The caller should call
+// Close when finished, to shut it down.
+func NewServer(configuration Configuration) *Server {
+	if configuration.ServiceID == "" {
+		configuration.ServiceID = newID()
+	}
+
+	if configuration.FromEmail == "" {
+		configuration.FromEmail = "test.service@notifications.service.gov.uk"
+	}
+
+	if configuration.FromNumber == "" {
+		configuration.FromNumber = "GOVUK"
+	}
+
+	if configuration.PageSize <= 0 {
+		configuration.PageSize = 250
+	}
+
+	if configuration.SendingDelay <= 0 {
+		configuration.SendingDelay = DefaultSendingDelay
+	}
+
+	if configuration.DeliveryDelay <= 0 {
+		configuration.DeliveryDelay = DefaultDeliveryDelay
+	}
+
+	if configuration.Seed == 0 {
+		configuration.Seed = time.Now().UnixNano()
+	}
+
+	if configuration.InboundNumber == "" {
+		configuration.InboundNumber = "07700900500"
+	}
+
+	if configuration.CallbackClient == nil {
+		configuration.CallbackClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	s := &Server{
+		configuration: configuration,
+		now:           time.Now,
+		templates:     map[string]Template{},
+		outcomes:      map[string]notify.Status{},
+		requests:      map[*apiKey][]time.Time{},
+		rand:          rand.New(rand.NewSource(configuration.Seed)),
+		done:          make(chan struct{}),
+	}
+
+	s.queueCond = sync.NewCond(&s.queueMu)
+	go s.deliver()
+
+	if configuration.Clock != nil {
+		s.now = configuration.Clock.Now
+		s.unsubscribe = configuration.Clock.subscribe(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			s.update()
+		})
+	} else if configuration.DeliveryReceipts != nil {
+		go s.tick()
+	}
+
+	s.httpServer = httptest.NewServer(s)
+	s.URL = s.httpServer.URL
+
+	return s
+}
+
+// Close shuts down the server. The callbacks not yet posted are dropped, so
+// call Flush first to wait for them.
+func (s *Server) Close() {
+	s.httpServer.Close()
+
+	if s.unsubscribe != nil {
+		s.unsubscribe()
+	}
+
+	s.queueMu.Lock()
+	defer s.queueMu.Unlock()
+
+	if !s.closed {
+		s.closed = true
+		close(s.done)
+		s.queueCond.Broadcast()
+	}
+}
+
+// ServeHTTP injects a fault into the request, if one matches it, or serves it.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if f := s.fault(r); f != nil {
+		s.inject(w, r, f)
+		return
+	}
+
+	s.serve(w, r)
+}
+
+// serve routes the request to the endpoint implementing it.
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+
+	switch {
+	case path == "/v2/notifications":
+		s.route(w, r, http.MethodGet, s.listNotifications)
+	case path == "/v2/notifications/email":
+		s.route(w, r, http.MethodPost, s.sendEmail)
+	case path == "/v2/notifications/sms":
+		s.route(w, r, http.MethodPost, s.sendSms)
+	case path == "/v2/notifications/letter":
+		s.route(w, r, http.MethodPost, s.sendLetter)
+	case strings.HasPrefix(path, "/v2/notifications/") && strings.Count(path, "/") == 3:
+		s.route(w, r, http.MethodGet, s.getNotification)
+	case path == notify.PathReceivedTextMessageList:
+		s.route(w, r, http.MethodGet, s.listReceivedTextMessages)
+	case path == PathReceiveTextMessage && r.Method == http.MethodPost:
+		s.receiveTextMessage(w, r)
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{
+			"result":  "error",
+			"message": "The requested URL was not found on the server.",
+		})
+	}
+}
+
+// handler of an endpoint, called with the API key the request was
+// authenticated with.
+type handler func(w http.ResponseWriter, r *http.Request, key *apiKey)
+
+func (s *Server) route(w http.ResponseWriter, r *http.Request, method string, h handler) {
+	if r.Method != method {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{
+			"result":  "error",
+			"message": "The method is not allowed for the requested URL.",
+		})
+		return
+	}
+
+	key, err := s.authenticate(r)
+	if err != nil {
+		err.write(w)
+		return
+	}
+
+	h(w, r, key)
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	json.NewEncoder(w).Encode(body)
+}
+
+func (e *requestError) write(w http.ResponseWriter) {
+	writeError(w, e.statusCode, e.name, e.message)
+}
+
+// writeError writes the error envelope returned by GOV.UK Notify.
+func writeError(w http.ResponseWriter, statusCode int, name, message string) {
+	writeJSON(w, statusCode, map[string]interface{}{
+		"status_code": statusCode,
+		"errors": []map[string]string{
+			{"error": name, "message": message},
+		},
+	})
+}
+
+// newID returns a random version 4 UUID.
+func newID() string {
+	b := make([]byte, 16)
+	crand.Read(b)
+
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,82 @@
+package notifytest
+
+import (
+	"net/http"
+	"net/url"
+
+	notify "github.com/alphagov/notifications-go-client"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	emailTemplateID  = "df10a23e-2c0d-4ea5-87fb-82e520cbf93c"
+	smsTemplateID    = "df10a23e-2c0d-4ea5-87fb-82e520cbf93d"
+	letterTemplateID = "df10a23e-2c0d-4ea5-87fb-82e520cbf93e"
+)
+
+// newServer returns a server with a template of every type, and a client
+// pointed at it using a normal API key.
+func newServer(configuration Configuration) (*Server, *notify.Client) {
+	server := NewServer(configuration)
+
+	server.AddTemplate(Template{ID: emailTemplateID, Type: notify.TemplateTypeEmail, Subject: "Hello ((name))", Body: "Your reference is ((ref))"})
+	server.AddTemplate(Template{ID: smsTemplateID, Type: notify.TemplateTypeSms, Body: "Your code is ((code))"})
+	server.AddTemplate(Template{ID: letterTemplateID, Type: notify.TemplateTypeLetter, Subject: "Your application", Body: "Dear ((address_line_1))"})
+
+	return server, newClient(server, server.AddAPIKey("test", KeyTypeNormal))
+}
+
+// newClient returns a client pointed at the server, using the API key.
+func newClient(server *Server, apiKey string) *notify.Client {
+	config, _ := notify.NewConfiguration(apiKey)
+	config.BaseURL, _ = url.Parse(server.URL)
+
+	client, _ := notify.New(config)
+
+	return client
+}
+
+var _ = Describe("Server", func() {
+	var server *Server
+
+	BeforeEach(func() {
+		server, _ = newServer(Configuration{})
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should require a token", func() {
+		res, err := http.Get(server.URL + "/v2/notifications")
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(res.StatusCode).To(Equal(http.StatusUnauthorized))
+	})
+
+	It("should not find an unknown endpoint", func() {
+		req, _ := http.NewRequest("GET", server.URL+"/v2/unknown", nil)
+		req.Header.Set("Authorization", "Bearer token")
+
+		res, err := http.DefaultClient.Do(req)
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(res.StatusCode).To(Equal(http.StatusNotFound))
+	})
+
+	It("should not allow an unsupported method", func() {
+		req, _ := http.NewRequest("GET", server.URL+"/v2/notifications/email", nil)
+		req.Header.Set("Authorization", "Bearer token")
+
+		res, err := http.DefaultClient.Do(req)
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(res.StatusCode).To(Equal(http.StatusMethodNotAllowed))
+	})
+
+	It("should generate version 4 UUIDs", func() {
+		Expect(newID()).To(MatchRegexp(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`))
+	})
+})
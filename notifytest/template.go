@@ -0,0 +1,77 @@
+package notifytest
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	notify "github.com/alphagov/notifications-go-client"
+)
+
+// Template known to the fake server. Notifications can only be sent with a
+// template that has been added to the server.
+//
+// The Subject and Body may contain placeholders, such as ((name)), which are
+// replaced by the personalisation sent with the notification.
+type Template struct {
+	ID      string
+	Type    notify.TemplateType
+	Version int64
+	Subject string
+	Body    string
+}
+
+// placeholderPattern matches a ((placeholder)) in a template.
+var placeholderPattern = regexp.MustCompile(`\(\(([^()]+)\)\)`)
+
+// AddTemplate adds the template to the server, replacing any template with
+// the same ID. The Version defaults to 1.
+func (s *Server) AddTemplate(t Template) {
+	if t.Version == 0 {
+		t.Version = 1
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.templates[strings.ToLower(t.ID)] = t
+}
+
+func (s *Server) template(id string) (Template, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.templates[strings.ToLower(id)]
+
+	return t, ok
+}
+
+func (s *Server) templateURI(id string) string {
+	return fmt.Sprintf("%s/services/%s/templates/%s", s.URL, s.configuration.ServiceID, id)
+}
+
+// render replaces the placeholders of the text with the personalisation,
+// matching their names without regard to case as GOV.UK Notify does. The
+// missing placeholders are returned.
+func render(text string, personalisation map[string]interface{}) (string, []string) {
+	values := map[string]interface{}{}
+	for k, v := range personalisation {
+		values[strings.ToLower(k)] = v
+	}
+
+	var missing []string
+
+	rendered := placeholderPattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+		name := strings.TrimSpace(placeholder[2 : len(placeholder)-2])
+
+		v, ok := values[strings.ToLower(name)]
+		if !ok || v == nil {
+			missing = append(missing, name)
+			return placeholder
+		}
+
+		return fmt.Sprint(v)
+	})
+
+	return rendered, missing
+}
@@ -1,15 +1,116 @@
 package notify
 
-import "net/url"
+import (
+	"errors"
+	"net/url"
+	"time"
+)
 
 // Payload that will be send with different set of requests by the client.
 type Payload struct {
-	EmailAddress    string            `json:"email_address"`
-	Letter          string            `json:"letter"` // TODO Establish what this actually is and prepare for usage.
-	Personalisation map[string]string `json:"personalisation"`
-	PhoneNumber     string            `json:"phone_number"`
-	Reference       string            `json:"reference"`
-	TemplateID      string            `json:"template_id"`
+	EmailAddress           string                 `json:"email_address,omitempty"`
+	EmailReplyToID         string                 `json:"email_reply_to_id,omitempty"`
+	OneClickUnsubscribeURL string                 `json:"one_click_unsubscribe_url,omitempty"`
+	Personalisation        map[string]interface{} `json:"personalisation,omitempty"`
+	PhoneNumber            string                 `json:"phone_number,omitempty"`
+	Postage                Postage                `json:"postage,omitempty"`
+	Reference              string                 `json:"reference,omitempty"`
+	ScheduledFor           string                 `json:"scheduled_for,omitempty"`
+	SmsSenderID            string                 `json:"sms_sender_id,omitempty"`
+	TemplateID             string                 `json:"template_id"`
+}
+
+// MaxScheduleAhead is how far in the future a message can be scheduled.
+const MaxScheduleAhead = 24 * time.Hour
+
+// scheduledForLayout is the format of scheduled_for, in the Europe/London time
+// zone, expected by the API.
+const scheduledForLayout = "2006-01-02 15:04"
+
+// SendOption sets an optional field of the Payload when sending an email or a
+// text message.
+type SendOption func(p *Payload) error
+
+// WithEmailReplyToID sets the ID of the reply-to email address to be used
+// instead of the service default. It can only be used when sending an email.
+func WithEmailReplyToID(id string) SendOption {
+	return func(p *Payload) error {
+		if p.EmailAddress == "" {
+			return errors.New("payload: email_reply_to_id can only be set on an email")
+		}
+
+		p.EmailReplyToID = id
+
+		return nil
+	}
+}
+
+// WithOneClickUnsubscribeURL sets the URL the recipient can use to unsubscribe
+// with one click. It can only be used when sending an email.
+func WithOneClickUnsubscribeURL(url string) SendOption {
+	return func(p *Payload) error {
+		if p.EmailAddress == "" {
+			return errors.New("payload: one_click_unsubscribe_url can only be set on an email")
+		}
+
+		p.OneClickUnsubscribeURL = url
+
+		return nil
+	}
+}
+
+// WithScheduledFor makes Notify hold the message until the given time, which
+// must be in the future and no more than MaxScheduleAhead away. The time is
+// sent to the minute, rounded up so that it is never in the past.
+func WithScheduledFor(t time.Time) SendOption {
+	return func(p *Payload) error {
+		if rounded := t.Truncate(time.Minute); !rounded.Equal(t) {
+			t = rounded.Add(time.Minute)
+		}
+
+		now := time.Now()
+		if t.Before(now) {
+			return errors.New("payload: scheduled_for can not be in the past")
+		}
+
+		if t.After(now.Add(MaxScheduleAhead)) {
+			return errors.New("payload: scheduled_for can only be 24 hours in the future")
+		}
+
+		loc, err := time.LoadLocation("Europe/London")
+		if err != nil {
+			return err
+		}
+
+		p.ScheduledFor = t.In(loc).Format(scheduledForLayout)
+
+		return nil
+	}
+}
+
+// WithSmsSenderID sets the ID of the text message sender to be used instead of
+// the service default. It can only be used when sending a text message.
+func WithSmsSenderID(id string) SendOption {
+	return func(p *Payload) error {
+		if p.PhoneNumber == "" {
+			return errors.New("payload: sms_sender_id can only be set on a text message")
+		}
+
+		p.SmsSenderID = id
+
+		return nil
+	}
+}
+
+func (p *Payload) apply(options []SendOption) error {
+	for _, option := range options {
+		err := option(p)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (p *Payload) addIfNotEmpty(m *url.Values, key, value string) {
@@ -32,8 +133,19 @@ func NewPayload(service, recipient, templateID string, personalisation templateD
 		p.PhoneNumber = recipient
 	case "email":
 		p.EmailAddress = recipient
-	case "letter":
-		p.Letter = recipient
+	}
+
+	return &p
+}
+
+// NewLetterPayload initialises the Payload struct for a letter, merging the
+// address into the personalisation.
+func NewLetterPayload(address LetterAddress, templateID string, personalisation templateData, reference string, postage Postage) *Payload {
+	p := Payload{
+		Personalisation: address.merge(personalisation),
+		Postage:         postage,
+		Reference:       reference,
+		TemplateID:      templateID,
 	}
 
 	return &p
@@ -1,7 +1,9 @@
 package notify
 
 import (
+	"encoding/json"
 	"net/url"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -28,7 +30,7 @@ var _ = Describe("Payload", func() {
 	It("should be able to run NewPayload() for sms service", func() {
 		phoneNumber = "00000000000"
 		templateID := "12345"
-		personalisation := map[string]string{}
+		personalisation := map[string]interface{}{}
 		reference := "123456qwerty"
 
 		payload = NewPayload("sms", phoneNumber, templateID, personalisation, reference)
@@ -36,13 +38,12 @@ var _ = Describe("Payload", func() {
 		Expect(payload.PhoneNumber).To(Equal(phoneNumber))
 		Expect(payload.Reference).To(Equal(reference))
 		Expect(payload.EmailAddress).To(BeEmpty())
-		Expect(payload.Letter).To(BeEmpty())
 	})
 
 	It("should be able to run NewPayload() for email service", func() {
 		emailAddress := "test@example.com"
 		templateID := "12345"
-		personalisation := map[string]string{}
+		personalisation := map[string]interface{}{}
 		reference := "123456qwerty"
 
 		p := NewPayload("email", emailAddress, templateID, personalisation, reference)
@@ -50,20 +51,99 @@ var _ = Describe("Payload", func() {
 		Expect(p.EmailAddress).To(Equal(emailAddress))
 		Expect(p.Reference).To(Equal(reference))
 		Expect(p.PhoneNumber).To(BeEmpty())
-		Expect(p.Letter).To(BeEmpty())
 	})
 
-	It("should be able to run NewPayload() for letter service", func() {
-		letter := "xxx"
+	It("should be able to run NewLetterPayload()", func() {
+		address := LetterAddress{
+			AddressLine1: "The Occupier",
+			AddressLine2: "123 High Street",
+			AddressLine3: "SW14 6BF",
+		}
 		templateID := "12345"
-		personalisation := map[string]string{}
+		personalisation := map[string]interface{}{"name": "Betty"}
 		reference := "123456qwerty"
 
-		p := NewPayload("letter", letter, templateID, personalisation, reference)
+		p := NewLetterPayload(address, templateID, personalisation, reference, PostageFirst)
 
-		Expect(p.Letter).To(Equal(letter))
+		Expect(p.Personalisation["address_line_1"]).To(Equal("The Occupier"))
+		Expect(p.Personalisation["address_line_3"]).To(Equal("SW14 6BF"))
+		Expect(p.Personalisation["name"]).To(Equal("Betty"))
+		Expect(p.Postage).To(Equal(PostageFirst))
 		Expect(p.Reference).To(Equal(reference))
 		Expect(p.EmailAddress).To(BeEmpty())
 		Expect(p.PhoneNumber).To(BeEmpty())
 	})
+
+	It("should omit the empty fields when encoding the Payload", func() {
+		p := NewPayload("sms", "00000000000", "12345", nil, "")
+
+		b, err := json.Marshal(p)
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(string(b)).To(Equal(`{"phone_number":"00000000000","template_id":"12345"}`))
+	})
+
+	It("should apply() the email options", func() {
+		p := NewPayload("email", "test@example.com", "12345", nil, "")
+
+		err := p.apply([]SendOption{
+			WithEmailReplyToID("8e222534-7f05-4972-86e3-17c5d9f894e2"),
+			WithOneClickUnsubscribeURL("https://example.com/unsubscribe"),
+		})
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(p.EmailReplyToID).To(Equal("8e222534-7f05-4972-86e3-17c5d9f894e2"))
+		Expect(p.OneClickUnsubscribeURL).To(Equal("https://example.com/unsubscribe"))
+	})
+
+	It("should apply() the sms options", func() {
+		p := NewPayload("sms", "00000000000", "12345", nil, "")
+
+		err := p.apply([]SendOption{WithSmsSenderID("8e222534-7f05-4972-86e3-17c5d9f894e2")})
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(p.SmsSenderID).To(Equal("8e222534-7f05-4972-86e3-17c5d9f894e2"))
+	})
+
+	It("should fail to apply() the options of another service", func() {
+		sms := NewPayload("sms", "00000000000", "12345", nil, "")
+		email := NewPayload("email", "test@example.com", "12345", nil, "")
+
+		Expect(sms.apply([]SendOption{WithEmailReplyToID("x")})).Should(HaveOccurred())
+		Expect(sms.apply([]SendOption{WithOneClickUnsubscribeURL("x")})).Should(HaveOccurred())
+		Expect(email.apply([]SendOption{WithSmsSenderID("x")})).Should(HaveOccurred())
+	})
+
+	It("should apply() the scheduled_for option in Europe/London time", func() {
+		loc, _ := time.LoadLocation("Europe/London")
+		t := time.Now().Add(time.Hour).Truncate(time.Minute).UTC()
+		p := NewPayload("email", "test@example.com", "12345", nil, "")
+
+		err := p.apply([]SendOption{WithScheduledFor(t)})
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(p.ScheduledFor).To(Equal(t.In(loc).Format("2006-01-02 15:04")))
+	})
+
+	It("should round the scheduled_for option up to the next minute", func() {
+		loc, _ := time.LoadLocation("Europe/London")
+		t := time.Now().Add(20 * time.Second)
+		p := NewPayload("sms", "00000000000", "12345", nil, "")
+
+		err := p.apply([]SendOption{WithScheduledFor(t)})
+
+		Expect(err).ShouldNot(HaveOccurred())
+
+		scheduled, _ := time.ParseInLocation("2006-01-02 15:04", p.ScheduledFor, loc)
+		Expect(scheduled).To(BeTemporally(">=", t))
+		Expect(scheduled).To(BeTemporally("<", t.Add(time.Minute)))
+	})
+
+	It("should fail to apply() the scheduled_for option outside of the allowed window", func() {
+		p := NewPayload("sms", "00000000000", "12345", nil, "")
+
+		Expect(p.apply([]SendOption{WithScheduledFor(time.Now().Add(-time.Minute))})).Should(HaveOccurred())
+		Expect(p.apply([]SendOption{WithScheduledFor(time.Now().Add(MaxScheduleAhead + time.Minute))})).Should(HaveOccurred())
+		Expect(p.ScheduledFor).To(BeEmpty())
+	})
 })
@@ -0,0 +1,108 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned, without making the request, when the RateLimit
+// of the client is exceeded and set to FailFast.
+var ErrRateLimited = errors.New("ratelimit: rate limit of the client exceeded")
+
+// RateLimit of the requests made by the client, shared by all the goroutines
+// using it. Every request counts, including retries.
+type RateLimit struct {
+	// Requests allowed per Interval. Up to Requests can be made at once. They
+	// are taken from DefaultRateLimit when not set.
+	Requests int
+	Interval time.Duration
+	// FailFast makes requests over the limit fail with ErrRateLimited, instead
+	// of waiting for their turn.
+	FailFast bool
+}
+
+// DefaultRateLimit matches the limit GOV.UK Notify applies to every API key.
+var DefaultRateLimit = RateLimit{
+	Requests: 3000,
+	Interval: time.Minute,
+}
+
+// limiter is a token bucket, refilled at the rate of the RateLimit.
+type limiter struct {
+	mu       sync.Mutex
+	capacity float64
+	failFast bool
+	last     time.Time
+	perToken time.Duration
+	tokens   float64
+}
+
+func newLimiter(limit RateLimit) (*limiter, error) {
+	if limit.Requests < 0 || limit.Interval < 0 {
+		return nil, errors.New("ratelimit: requests and interval must not be negative")
+	}
+
+	if limit.Requests == 0 {
+		limit.Requests = DefaultRateLimit.Requests
+	}
+
+	if limit.Interval == 0 {
+		limit.Interval = DefaultRateLimit.Interval
+	}
+
+	l := limiter{
+		capacity: float64(limit.Requests),
+		failFast: limit.FailFast,
+		last:     time.Now(),
+		perToken: limit.Interval / time.Duration(limit.Requests),
+		tokens:   float64(limit.Requests),
+	}
+
+	return &l, nil
+}
+
+// wait takes a token from the bucket, waiting until one is available unless
+// the limiter fails fast. Tokens are handed out in the order they are asked
+// for.
+func (l *limiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+
+	now := time.Now()
+	l.tokens += float64(now.Sub(l.last)) / float64(l.perToken)
+	if l.tokens > l.capacity {
+		l.tokens = l.capacity
+	}
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		l.mu.Unlock()
+		return nil
+	}
+
+	if l.failFast {
+		l.mu.Unlock()
+		return ErrRateLimited
+	}
+
+	// Reserve the token now and wait until it has been refilled.
+	l.tokens--
+	delay := time.Duration(-l.tokens * float64(l.perToken))
+	l.mu.Unlock()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+
+		return ctx.Err()
+	}
+}
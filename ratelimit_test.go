@@ -0,0 +1,129 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	httpmock "gopkg.in/jarcoal/httpmock.v1"
+)
+
+var _ = Describe("RateLimit", func() {
+	It("should fail to create newLimiter() with a negative rate", func() {
+		_, err := newLimiter(RateLimit{Requests: -1})
+		Expect(err).Should(HaveOccurred())
+
+		_, err = newLimiter(RateLimit{Interval: -time.Second})
+		Expect(err).Should(HaveOccurred())
+	})
+
+	It("should default newLimiter() to the DefaultRateLimit", func() {
+		l, err := newLimiter(RateLimit{FailFast: true})
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(l.capacity).To(Equal(float64(DefaultRateLimit.Requests)))
+		Expect(l.perToken).To(Equal(DefaultRateLimit.Interval / time.Duration(DefaultRateLimit.Requests)))
+		Expect(l.failFast).To(BeTrue())
+
+		l, _ = newLimiter(RateLimit{Requests: 60})
+		Expect(l.perToken).To(Equal(time.Second))
+
+		u, _ := url.Parse("https://example.com")
+		_, err = New(Configuration{
+			APIKey:    []byte("secret"),
+			BaseURL:   u,
+			RateLimit: &RateLimit{FailFast: true},
+			ServiceID: "test",
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	It("should let a burst of Requests through at once", func() {
+		l, _ := newLimiter(RateLimit{Requests: 5, Interval: time.Hour, FailFast: true})
+
+		for i := 0; i < 5; i++ {
+			Expect(l.wait(context.Background())).To(Succeed())
+		}
+
+		Expect(l.wait(context.Background())).To(Equal(ErrRateLimited))
+	})
+
+	It("should wait() for a token to be refilled", func() {
+		l, _ := newLimiter(RateLimit{Requests: 2, Interval: 100 * time.Millisecond})
+
+		start := time.Now()
+		for i := 0; i < 4; i++ {
+			Expect(l.wait(context.Background())).To(Succeed())
+		}
+
+		Expect(time.Since(start)).To(BeNumerically(">=", 90*time.Millisecond))
+	})
+
+	It("should share the tokens between goroutines", func() {
+		l, _ := newLimiter(RateLimit{Requests: 10, Interval: time.Hour, FailFast: true})
+
+		var (
+			wg      sync.WaitGroup
+			mu      sync.Mutex
+			allowed int
+		)
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				if l.wait(context.Background()) == nil {
+					mu.Lock()
+					allowed++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		Expect(allowed).To(Equal(10))
+	})
+
+	It("should stop waiting when the context is done", func() {
+		l, _ := newLimiter(RateLimit{Requests: 1, Interval: time.Hour})
+		Expect(l.wait(context.Background())).To(Succeed())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		Expect(l.wait(ctx)).To(Equal(context.DeadlineExceeded))
+	})
+
+	It("should limit the requests of the Client without retrying them", func() {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		u, _ := url.Parse("https://example.com")
+		client, err := New(Configuration{
+			APIKey:    []byte("secret"),
+			BaseURL:   u,
+			RateLimit: &RateLimit{Requests: 1, Interval: time.Hour, FailFast: true},
+			RetryPolicy: &RetryPolicy{
+				MaxAttempts: 3,
+				BaseDelay:   time.Millisecond,
+				MaxDelay:    time.Millisecond,
+			},
+			ServiceID: "test",
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		httpmock.RegisterResponder("GET", "https://example.com/v2/notifications/n0t1-1234567890",
+			httpmock.NewStringResponder(http.StatusOK, `{"id":"n0t1-1234567890"}`))
+
+		_, err = client.GetNotification("n0t1-1234567890")
+		Expect(err).ShouldNot(HaveOccurred())
+
+		_, err = client.GetNotification("n0t1-1234567890")
+		Expect(err).To(Equal(ErrRateLimited))
+		Expect(httpmock.GetTotalCallCount()).To(Equal(1))
+	})
+})
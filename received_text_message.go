@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ReceivedTextMessage is a text message sent by a user to the service's inbound
+// number, as returned by GOV.UK Notify.
+type ReceivedTextMessage struct {
+	ID           string    `json:"id"`
+	UserNumber   string    `json:"user_number"`
+	NotifyNumber string    `json:"notify_number"`
+	ServiceID    string    `json:"service_id"`
+	Content      string    `json:"content"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ReceivedTextMessageList is one the responses from GOV.UK Notify.
+type ReceivedTextMessageList struct {
+	Client *Client `json:"-"`
+
+	ReceivedTextMessages []ReceivedTextMessage `json:"received_text_messages"`
+	Links                Pagination            `json:"links"`
+}
+
+// Next page of the list should be loaded in place of the old one.
+func (rl *ReceivedTextMessageList) Next() error {
+	return rl.NextContext(context.Background())
+}
+
+// NextContext is like Next, but uses ctx for the request.
+func (rl *ReceivedTextMessageList) NextContext(ctx context.Context) error {
+	if rl.Links.Next == "" {
+		return errors.New("pagination: already on last page")
+	}
+
+	return rl.load(ctx, rl.Links.Next)
+}
+
+// Previous page of the list should be loaded in place of the old one.
+func (rl *ReceivedTextMessageList) Previous() error {
+	return rl.PreviousContext(context.Background())
+}
+
+// PreviousContext is like Previous, but uses ctx for the request.
+func (rl *ReceivedTextMessageList) PreviousContext(ctx context.Context) error {
+	if rl.Links.Previous == "" {
+		return errors.New("pagination: already on first page")
+	}
+
+	return rl.load(ctx, rl.Links.Previous)
+}
+
+func (rl *ReceivedTextMessageList) load(ctx context.Context, path string) error {
+	list := ReceivedTextMessageList{Client: rl.Client}
+
+	res, err := rl.Client.httpGet(ctx, path, nil)
+	if err != nil {
+		return err
+	}
+
+	err = rl.Client.handleInvalidResponse(res)
+	if err != nil {
+		return err
+	}
+
+	err = jsonResponse(res.Body, &list)
+	if err != nil {
+		return err
+	}
+
+	*rl = list
+
+	return nil
+}
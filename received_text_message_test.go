@@ -0,0 +1,84 @@
+package notify
+
+import (
+	"net/http"
+	"net/url"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	httpmock "gopkg.in/jarcoal/httpmock.v1"
+)
+
+var _ = Describe("ReceivedTextMessage", func() {
+	Context("List", func() {
+		var (
+			client *Client
+		)
+
+		BeforeEach(func() {
+			httpmock.Activate()
+
+			u, _ := url.Parse("https://example.com")
+
+			config := Configuration{
+				APIKey: []byte(`#5K+･ｼミew{ｦ住ｳ(跼Tﾉ(ｩ┫ﾒP.ｿﾓ燾辻G�感ﾃwb="=.!r.Oﾀﾍ奎gﾐ｣`),
+				BaseURL:   u,
+				ServiceID: "test",
+			}
+
+			client, _ = New(config)
+		})
+
+		AfterEach(func() {
+			httpmock.DeactivateAndReset()
+		})
+
+		It("should allow to ListReceivedTextMessages()", func() {
+			httpmock.RegisterResponder("GET", "https://example.com/v2/received-text-messages",
+				httpmock.NewStringResponder(http.StatusOK, `{"received_text_messages":[{"id":"r3c1-1234567890","user_number":"447700900111","notify_number":"07700900000","service_id":"test","content":"Hello","created_at":"2017-11-02T15:07:57.197546Z"}],"links":{"current":"/v2/received-text-messages","next":"/v2/received-text-messages?older_than=r3c1-1234567890"}}`))
+
+			list, err := client.ListReceivedTextMessages("")
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(list.ReceivedTextMessages).To(HaveLen(1))
+			Expect(list.ReceivedTextMessages[0].UserNumber).To(Equal("447700900111"))
+			Expect(list.ReceivedTextMessages[0].Content).To(Equal("Hello"))
+			Expect(list.Links.Next).NotTo(BeEmpty())
+		})
+
+		It("should loead the Next() page", func() {
+			httpmock.RegisterResponder("GET", "https://example.com/v2/received-text-messages",
+				httpmock.NewStringResponder(http.StatusOK, `{"received_text_messages":[{"id":"r3c1-1234567890"}],"links":{"current":"/v2/received-text-messages","next":"/v2/received-text-messages?older_than=r3c1-1234567890"}}`))
+			httpmock.RegisterResponder("GET", "https://example.com/v2/received-text-messages?older_than=r3c1-1234567890",
+				httpmock.NewStringResponder(http.StatusOK, `{"received_text_messages":[{"id":"r3c1-0123456789"}],"links":{"current":"/v2/received-text-messages?older_than=r3c1-1234567890"}}`))
+
+			list, err := client.ListReceivedTextMessages("")
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(list.ReceivedTextMessages[0].ID).To(Equal("r3c1-1234567890"))
+
+			err = list.Next()
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(list.ReceivedTextMessages[0].ID).To(Equal("r3c1-0123456789"))
+
+			err = list.Next()
+
+			Expect(err).Should(HaveOccurred())
+		})
+
+		It("should fail to load Previous() page", func() {
+			httpmock.RegisterResponder("GET", "https://example.com/v2/received-text-messages?older_than=r3c1-1234567890",
+				httpmock.NewStringResponder(http.StatusOK, `{"received_text_messages":[{"id":"r3c1-0123456789"}],"links":{"current":"/v2/received-text-messages?older_than=r3c1-1234567890"}}`))
+
+			list, err := client.ListReceivedTextMessages("r3c1-1234567890")
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(list.ReceivedTextMessages[0].ID).To(Equal("r3c1-0123456789"))
+
+			err = list.Previous()
+
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,313 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy of the client, applied to every request when set on the
+// Configuration.
+//
+// Requests are retried with exponential backoff and jitter after a connection
+// error, a server error or a RateLimitError. Validation errors and the daily
+// TooManyRequestsError are never retried. A Retry-After header sent by the API
+// is honoured instead of the backoff.
+//
+// Sends are only retried after a connection or server error when they can not
+// result in a message being sent twice: the notifications are first looked up
+// by the reference of the send and, if one with the same template and
+// recipient was created since the first attempt, it is returned instead of
+// sending again.
+type RetryPolicy struct {
+	// MaxAttempts of a request, including the first one.
+	MaxAttempts int
+	// BaseDelay is the longest delay before the first retry. It doubles with
+	// every attempt, up to MaxDelay.
+	BaseDelay time.Duration
+	// MaxDelay is the longest delay between two attempts.
+	MaxDelay time.Duration
+	// RetryUnreferencedSends allows sends without a reference to be retried
+	// after a connection or server error, at the risk of sending twice.
+	RetryUnreferencedSends bool
+}
+
+// DefaultRetryPolicy is a RetryPolicy suitable for most services.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    30 * time.Second,
+}
+
+type retryKind int
+
+const (
+	// retryNever for requests that succeeded or would fail again.
+	retryNever retryKind = iota
+	// retrySafe for requests rejected by the API before being processed.
+	retrySafe
+	// retryUnsafe for requests that may have been processed by the API.
+	retryUnsafe
+)
+
+// maxClockSkew between the client and GOV.UK Notify, which rejects tokens
+// issued more than 30 seconds away from its own clock.
+const maxClockSkew = 30 * time.Second
+
+// sendGuard describes a send, so that it is not repeated when retried.
+type sendGuard struct {
+	reference    string
+	templateType TemplateType
+	templateID   string
+	recipient    string
+	// since is the time of the first attempt of the send, less the clock skew
+	// allowed by GOV.UK Notify.
+	since time.Time
+}
+
+func sendGuardFor(payload interface{}) *sendGuard {
+	switch p := payload.(type) {
+	case *Payload:
+		guard := sendGuard{
+			reference:    p.Reference,
+			templateType: TemplateTypeLetter,
+			templateID:   p.TemplateID,
+			since:        time.Now().Add(-maxClockSkew),
+		}
+
+		if p.EmailAddress != "" {
+			guard.templateType = TemplateTypeEmail
+			guard.recipient = p.EmailAddress
+		} else if p.PhoneNumber != "" {
+			guard.templateType = TemplateTypeSms
+			guard.recipient = p.PhoneNumber
+		}
+
+		return &guard
+	case *precompiledLetterPayload:
+		return &sendGuard{reference: p.Reference, templateType: TemplateTypeLetter, since: time.Now().Add(-maxClockSkew)}
+	}
+
+	return nil
+}
+
+// withRetry makes the call as many times as allowed by the RetryPolicy. The
+// guard is nil for requests that can safely be repeated.
+func (c *Client) withRetry(ctx context.Context, guard *sendGuard, call func() (*http.Response, error)) (*http.Response, error) {
+	policy := c.configuration.RetryPolicy
+
+	for attempt := 1; ; attempt++ {
+		res, err := call()
+		if policy == nil || attempt >= policy.MaxAttempts || ctx.Err() != nil {
+			return res, err
+		}
+
+		kind, err := classifyAttempt(res, err)
+		if kind == retryNever {
+			return res, err
+		}
+
+		if kind == retryUnsafe && guard != nil {
+			if guard.reference == "" && !policy.RetryUnreferencedSends {
+				return res, err
+			}
+
+			if guard.reference != "" {
+				sent, lookupErr := c.lookupSent(ctx, guard)
+				if lookupErr != nil {
+					return res, err
+				}
+
+				if sent != nil {
+					closeResponse(res)
+					return sent, nil
+				}
+			}
+		}
+
+		delay := policy.backoff(attempt, res)
+		closeResponse(res)
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// classifyAttempt decides whether the attempt may be retried. The body of a
+// 429 response is read to tell the rate limit from the daily limit, and put
+// back in place.
+func classifyAttempt(res *http.Response, err error) (retryKind, error) {
+	if err == ErrRateLimited {
+		return retryNever, err
+	}
+
+	if err != nil {
+		return retryUnsafe, err
+	}
+
+	switch {
+	case res.StatusCode == http.StatusTooManyRequests:
+		b, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		res.Body = ioutil.NopCloser(bytes.NewReader(b))
+		if err != nil {
+			return retryNever, err
+		}
+
+		if bytes.Contains(b, []byte("TooManyRequestsError")) {
+			return retryNever, nil
+		}
+
+		return retrySafe, nil
+	case res.StatusCode >= http.StatusInternalServerError:
+		return retryUnsafe, nil
+	}
+
+	return retryNever, nil
+}
+
+// backoff returns the delay before the next attempt, taken from the
+// Retry-After header when sent by the API.
+func (p *RetryPolicy) backoff(attempt int, res *http.Response) time.Duration {
+	if res != nil {
+		if after := res.Header.Get("Retry-After"); after != "" {
+			if seconds, err := strconv.Atoi(after); err == nil {
+				return time.Duration(seconds) * time.Second
+			}
+
+			if t, err := http.ParseTime(after); err == nil {
+				return time.Until(t)
+			}
+		}
+	}
+
+	d := p.BaseDelay << uint(attempt-1)
+	if d > p.MaxDelay || d <= 0 {
+		d = p.MaxDelay
+	}
+
+	if d <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(d) + 1))
+}
+
+// lookupSent returns a response built from the notification created by an
+// earlier attempt of the send, or nil when there is none. A notification with
+// the same reference only matches when it was sent with the same template, to
+// the same recipient, since the first attempt, give or take the clock skew: a
+// reference may be shared by a batch of messages.
+func (c *Client) lookupSent(ctx context.Context, guard *sendGuard) (*http.Response, error) {
+	filters := Filters{Reference: guard.reference, TemplateType: guard.templateType}
+	q, err := filters.ToURLValues()
+	if err != nil {
+		return nil, err
+	}
+	u := fmt.Sprintf("%s%s?%s", c.configuration.BaseURL.String(), PathNotificationList, q.Encode())
+
+	res, err := c.httpCall(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.handleInvalidResponse(res)
+	if err != nil {
+		return nil, err
+	}
+
+	list := struct {
+		Notifications []struct {
+			ID        string          `json:"id"`
+			Reference string          `json:"reference"`
+			Email     string          `json:"email_address"`
+			Phone     string          `json:"phone_number"`
+			Body      string          `json:"body"`
+			Subject   string          `json:"subject"`
+			Postage   string          `json:"postage"`
+			CreatedAt time.Time       `json:"created_at"`
+			Template  json.RawMessage `json:"template"`
+		} `json:"notifications"`
+	}{}
+
+	err = jsonResponse(res.Body, &list)
+	if err != nil {
+		return nil, err
+	}
+
+	found := -1
+	for i, n := range list.Notifications {
+		var template struct {
+			ID string `json:"id"`
+		}
+		json.Unmarshal(n.Template, &template)
+
+		if guard.matches(template.ID, n.Email+n.Phone, n.CreatedAt) {
+			found = i
+			break
+		}
+	}
+
+	if found < 0 {
+		return nil, nil
+	}
+
+	n := list.Notifications[found]
+	entry := map[string]interface{}{
+		"id":        n.ID,
+		"reference": n.Reference,
+		"template":  n.Template,
+		"uri":       fmt.Sprintf("%s"+PathNotificationLookup, c.configuration.BaseURL.String(), n.ID),
+		"content": map[string]string{
+			"body":    n.Body,
+			"subject": n.Subject,
+		},
+	}
+	if n.Postage != "" {
+		entry["postage"] = n.Postage
+	}
+
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	sent := http.Response{
+		Status:     "201 Created",
+		StatusCode: http.StatusCreated,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       ioutil.NopCloser(bytes.NewReader(b)),
+	}
+
+	return &sent, nil
+}
+
+// matches reports whether a notification with the reference of the send was
+// created by one of its attempts.
+func (g *sendGuard) matches(templateID, recipient string, createdAt time.Time) bool {
+	if g.templateID != "" && templateID != g.templateID {
+		return false
+	}
+
+	if g.recipient != "" && !strings.EqualFold(strings.TrimSpace(recipient), strings.TrimSpace(g.recipient)) {
+		return false
+	}
+
+	return !createdAt.Before(g.since)
+}
+
+func closeResponse(res *http.Response) {
+	if res != nil && res.Body != nil {
+		res.Body.Close()
+	}
+}
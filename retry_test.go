@@ -0,0 +1,257 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	httpmock "gopkg.in/jarcoal/httpmock.v1"
+)
+
+// sequenceResponder replies with the responders in turn, repeating the last
+// one, and counts the calls made.
+func sequenceResponder(calls *int, responders ...httpmock.Responder) httpmock.Responder {
+	return func(req *http.Request) (*http.Response, error) {
+		i := *calls
+		if i >= len(responders) {
+			i = len(responders) - 1
+		}
+		*calls++
+
+		return responders[i](req)
+	}
+}
+
+var _ = Describe("Retry", func() {
+	var (
+		client *Client
+		config Configuration
+		calls  int
+	)
+
+	BeforeEach(func() {
+		httpmock.Activate()
+
+		u, _ := url.Parse("https://example.com")
+
+		config = Configuration{
+			APIKey:  []byte("secret"),
+			BaseURL: u,
+			RetryPolicy: &RetryPolicy{
+				MaxAttempts: 3,
+				BaseDelay:   time.Millisecond,
+				MaxDelay:    5 * time.Millisecond,
+			},
+			ServiceID: "test",
+		}
+		client, _ = New(config)
+
+		calls = 0
+	})
+
+	AfterEach(func() {
+		httpmock.DeactivateAndReset()
+	})
+
+	It("should make a single attempt without a RetryPolicy", func() {
+		config.RetryPolicy = nil
+		client, _ = New(config)
+		httpmock.RegisterResponder("GET", "https://example.com/v2/notifications/n0t1-1234567890",
+			sequenceResponder(&calls, httpmock.NewStringResponder(http.StatusServiceUnavailable, ``)))
+
+		_, err := client.GetNotification("n0t1-1234567890")
+
+		Expect(err).Should(HaveOccurred())
+		Expect(calls).To(Equal(1))
+	})
+
+	It("should retry a server error", func() {
+		httpmock.RegisterResponder("GET", "https://example.com/v2/notifications/n0t1-1234567890",
+			sequenceResponder(&calls,
+				httpmock.NewStringResponder(http.StatusServiceUnavailable, ``),
+				httpmock.NewStringResponder(http.StatusOK, `{"id":"n0t1-1234567890"}`),
+			))
+
+		notification, err := client.GetNotification("n0t1-1234567890")
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(notification.ID).To(Equal("n0t1-1234567890"))
+		Expect(calls).To(Equal(2))
+	})
+
+	It("should retry a connection error", func() {
+		httpmock.RegisterResponder("GET", "https://example.com/v2/notifications/n0t1-1234567890",
+			sequenceResponder(&calls,
+				httpmock.NewErrorResponder(errors.New("connection reset by peer")),
+				httpmock.NewStringResponder(http.StatusOK, `{"id":"n0t1-1234567890"}`),
+			))
+
+		_, err := client.GetNotification("n0t1-1234567890")
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(calls).To(Equal(2))
+	})
+
+	It("should give up after MaxAttempts", func() {
+		httpmock.RegisterResponder("GET", "https://example.com/v2/notifications/n0t1-1234567890",
+			sequenceResponder(&calls, httpmock.NewStringResponder(http.StatusBadGateway, `{"status_code":502,"errors":[{"error":"ServerError","message":"Bad gateway"}]}`)))
+
+		_, err := client.GetNotification("n0t1-1234567890")
+
+		Expect(err).Should(HaveOccurred())
+		Expect(calls).To(Equal(3))
+	})
+
+	It("should retry a RateLimitError honouring Retry-After", func() {
+		limited := httpmock.NewStringResponse(http.StatusTooManyRequests, `{"status_code":429,"errors":[{"error":"RateLimitError","message":"Exceeded rate limit for key type LIVE of 3000 requests per 60 seconds"}]}`)
+		limited.Header.Set("Retry-After", "0")
+
+		httpmock.RegisterResponder("POST", "https://example.com/v2/notifications/sms",
+			sequenceResponder(&calls,
+				httpmock.ResponderFromResponse(limited),
+				httpmock.NewStringResponder(http.StatusCreated, `{"id":"df10a23e-2c6d-4ea5-87fb-82e520cbf93a"}`),
+			))
+
+		res, err := client.SendSms("00000000000", "123456qwerty", templateData{}, "")
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(res.ID).To(Equal("df10a23e-2c6d-4ea5-87fb-82e520cbf93a"))
+		Expect(calls).To(Equal(2))
+	})
+
+	It("should not retry a TooManyRequestsError", func() {
+		httpmock.RegisterResponder("POST", "https://example.com/v2/notifications/sms",
+			sequenceResponder(&calls, httpmock.NewStringResponder(http.StatusTooManyRequests, `{"status_code":429,"errors":[{"error":"TooManyRequestsError","message":"Exceeded send limits (50) for today"}]}`)))
+
+		_, err := client.SendSms("00000000000", "123456qwerty", templateData{}, "")
+
+		Expect(err).Should(HaveOccurred())
+		Expect(err).To(BeAssignableToTypeOf(&TooManyRequestsError{}))
+		Expect(calls).To(Equal(1))
+	})
+
+	It("should not retry a validation error", func() {
+		httpmock.RegisterResponder("GET", "https://example.com/v2/notifications/n0t1-1234567890",
+			sequenceResponder(&calls, httpmock.NewStringResponder(http.StatusBadRequest, `{"status_code":400,"errors":[{"error":"ValidationError","message":"id is not a valid UUID"}]}`)))
+
+		_, err := client.GetNotification("n0t1-1234567890")
+
+		Expect(err).Should(HaveOccurred())
+		Expect(calls).To(Equal(1))
+	})
+
+	It("should not retry a send without a reference after a server error", func() {
+		httpmock.RegisterResponder("POST", "https://example.com/v2/notifications/email",
+			sequenceResponder(&calls, httpmock.NewStringResponder(http.StatusInternalServerError, `[]`)))
+
+		_, err := client.SendEmail("test@example.com", "123456qwerty", templateData{}, "")
+
+		Expect(err).Should(HaveOccurred())
+		Expect(calls).To(Equal(1))
+	})
+
+	It("should retry a send without a reference when RetryUnreferencedSends", func() {
+		config.RetryPolicy.RetryUnreferencedSends = true
+		client, _ = New(config)
+		httpmock.RegisterResponder("POST", "https://example.com/v2/notifications/email",
+			sequenceResponder(&calls,
+				httpmock.NewStringResponder(http.StatusInternalServerError, `[]`),
+				httpmock.NewStringResponder(http.StatusCreated, `{"id":"df10a23e-2c6d-4ea5-87fb-82e520cbf93a"}`),
+			))
+
+		_, err := client.SendEmail("test@example.com", "123456qwerty", templateData{}, "")
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(calls).To(Equal(2))
+	})
+
+	It("should retry a send with a reference that was not sent", func() {
+		httpmock.RegisterResponder("GET", "https://example.com/v2/notifications?reference=ref-1&template_type=email",
+			httpmock.NewStringResponder(http.StatusOK, `{"notifications":[],"links":{}}`))
+		httpmock.RegisterResponder("POST", "https://example.com/v2/notifications/email",
+			sequenceResponder(&calls,
+				httpmock.NewStringResponder(http.StatusInternalServerError, `[]`),
+				httpmock.NewStringResponder(http.StatusCreated, `{"id":"df10a23e-2c6d-4ea5-87fb-82e520cbf93a"}`),
+			))
+
+		res, err := client.SendEmail("test@example.com", "123456qwerty", templateData{}, "ref-1")
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(res.ID).To(Equal("df10a23e-2c6d-4ea5-87fb-82e520cbf93a"))
+		Expect(calls).To(Equal(2))
+	})
+
+	It("should not resend a send with a reference that was already sent", func() {
+		httpmock.RegisterResponder("GET", "https://example.com/v2/notifications?reference=ref-1&template_type=email",
+			httpmock.NewStringResponder(http.StatusOK, fmt.Sprintf(`{"notifications":[{"id":"n0t1-1234567890","reference":"ref-1","email_address":"test@example.com","template":{"id":"123456qwerty"},"created_at":%q,"body":"Hello","subject":"Hi"}],"links":{}}`,
+				time.Now().Add(time.Second).Format(time.RFC3339Nano))))
+		httpmock.RegisterResponder("POST", "https://example.com/v2/notifications/email",
+			sequenceResponder(&calls, httpmock.NewStringResponder(http.StatusBadGateway, ``)))
+
+		res, err := client.SendEmail("test@example.com", "123456qwerty", templateData{}, "ref-1")
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(res.ID).To(Equal("n0t1-1234567890"))
+		Expect(res.Reference).To(Equal("ref-1"))
+		Expect(res.URI).To(Equal("https://example.com/v2/notifications/n0t1-1234567890"))
+		Expect(calls).To(Equal(1))
+	})
+
+	It("should not resend a send created by a server with a clock behind the client", func() {
+		httpmock.RegisterResponder("GET", "https://example.com/v2/notifications?reference=ref-1&template_type=email",
+			httpmock.NewStringResponder(http.StatusOK, fmt.Sprintf(`{"notifications":[{"id":"n0t1-1234567890","reference":"ref-1","email_address":"test@example.com","template":{"id":"123456qwerty"},"created_at":%q}],"links":{}}`,
+				time.Now().Add(-5*time.Second).Format(time.RFC3339Nano))))
+		httpmock.RegisterResponder("POST", "https://example.com/v2/notifications/email",
+			sequenceResponder(&calls, httpmock.NewStringResponder(http.StatusBadGateway, ``)))
+
+		res, err := client.SendEmail("test@example.com", "123456qwerty", templateData{}, "ref-1")
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(res.ID).To(Equal("n0t1-1234567890"))
+		Expect(calls).To(Equal(1))
+	})
+
+	DescribeTable("should retry a send when the notification with its reference is another message",
+		func(notification string) {
+			httpmock.RegisterResponder("GET", "https://example.com/v2/notifications?reference=ref-1&template_type=email",
+				httpmock.NewStringResponder(http.StatusOK, `{"notifications":[`+notification+`],"links":{}}`))
+			httpmock.RegisterResponder("POST", "https://example.com/v2/notifications/email",
+				sequenceResponder(&calls,
+					httpmock.NewStringResponder(http.StatusInternalServerError, `[]`),
+					httpmock.NewStringResponder(http.StatusCreated, `{"id":"df10a23e-2c6d-4ea5-87fb-82e520cbf93a"}`),
+				))
+
+			res, err := client.SendEmail("test@example.com", "123456qwerty", templateData{}, "ref-1")
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(res.ID).To(Equal("df10a23e-2c6d-4ea5-87fb-82e520cbf93a"))
+			Expect(calls).To(Equal(2))
+		},
+		Entry("created before the first attempt",
+			`{"id":"n0t1-1234567890","reference":"ref-1","email_address":"test@example.com","template":{"id":"123456qwerty"},"created_at":"2020-01-01T09:00:00.000000Z"}`),
+		Entry("sent to another recipient",
+			fmt.Sprintf(`{"id":"n0t1-1234567890","reference":"ref-1","email_address":"other@example.com","template":{"id":"123456qwerty"},"created_at":%q}`,
+				time.Now().Add(time.Hour).Format(time.RFC3339Nano))),
+		Entry("sent with another template",
+			fmt.Sprintf(`{"id":"n0t1-1234567890","reference":"ref-1","email_address":"test@example.com","template":{"id":"other"},"created_at":%q}`,
+				time.Now().Add(time.Hour).Format(time.RFC3339Nano))),
+	)
+
+	It("should compute the backoff() within the bounds of the policy", func() {
+		policy := RetryPolicy{BaseDelay: 10 * time.Millisecond, MaxDelay: 25 * time.Millisecond}
+
+		for i := 0; i < 20; i++ {
+			Expect(policy.backoff(1, nil)).To(BeNumerically("<=", 10*time.Millisecond))
+			Expect(policy.backoff(5, nil)).To(BeNumerically("<=", 25*time.Millisecond))
+		}
+
+		res := httpmock.NewStringResponse(http.StatusTooManyRequests, ``)
+		res.Header.Set("Retry-After", "2")
+		Expect(policy.backoff(1, res)).To(Equal(2 * time.Second))
+	})
+})
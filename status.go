@@ -0,0 +1,85 @@
+package notify
+
+// Status of a notification.
+type Status string
+
+// Statuses of emails and text messages.
+const (
+	StatusCreated          Status = "created"
+	StatusSending          Status = "sending"
+	StatusPending          Status = "pending"
+	StatusSent             Status = "sent"
+	StatusDelivered        Status = "delivered"
+	StatusPermanentFailure Status = "permanent-failure"
+	StatusTemporaryFailure Status = "temporary-failure"
+	StatusTechnicalFailure Status = "technical-failure"
+)
+
+// Statuses of letters. Letters may also be in StatusTechnicalFailure and
+// StatusPermanentFailure.
+const (
+	StatusAccepted          Status = "accepted"
+	StatusReceived          Status = "received"
+	StatusCancelled         Status = "cancelled"
+	StatusPendingVirusCheck Status = "pending-virus-check"
+	StatusVirusScanFailed   Status = "virus-scan-failed"
+	StatusValidationFailed  Status = "validation-failed"
+)
+
+// StatusFailed is only used to filter notifications, matching all of
+// StatusPermanentFailure, StatusTemporaryFailure and StatusTechnicalFailure.
+const StatusFailed Status = "failed"
+
+// IsValid reports whether the status is known to GOV.UK Notify.
+func (s Status) IsValid() bool {
+	switch s {
+	case StatusCreated, StatusSending, StatusPending, StatusSent, StatusDelivered,
+		StatusPermanentFailure, StatusTemporaryFailure, StatusTechnicalFailure,
+		StatusAccepted, StatusReceived, StatusCancelled, StatusPendingVirusCheck,
+		StatusVirusScanFailed, StatusValidationFailed, StatusFailed:
+		return true
+	}
+
+	return false
+}
+
+// IsFinal reports whether the notification will not change status anymore.
+func (s Status) IsFinal() bool {
+	switch s {
+	case StatusSent, StatusDelivered, StatusReceived, StatusCancelled:
+		return true
+	}
+
+	return s.IsFailure()
+}
+
+// IsFailure reports whether the notification failed to be delivered.
+func (s Status) IsFailure() bool {
+	switch s {
+	case StatusFailed, StatusPermanentFailure, StatusTemporaryFailure, StatusTechnicalFailure,
+		StatusVirusScanFailed, StatusValidationFailed:
+		return true
+	}
+
+	return false
+}
+
+// TemplateType of a template, and of the notifications sent with it.
+type TemplateType string
+
+// Template types accepted by GOV.UK Notify.
+const (
+	TemplateTypeEmail  TemplateType = "email"
+	TemplateTypeSms    TemplateType = "sms"
+	TemplateTypeLetter TemplateType = "letter"
+)
+
+// IsValid reports whether the template type is known to GOV.UK Notify.
+func (t TemplateType) IsValid() bool {
+	switch t {
+	case TemplateTypeEmail, TemplateTypeSms, TemplateTypeLetter:
+		return true
+	}
+
+	return false
+}
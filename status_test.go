@@ -0,0 +1,40 @@
+package notify
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Status", func() {
+	It("should tell the final statuses with IsFinal()", func() {
+		for _, s := range []Status{StatusSent, StatusDelivered, StatusPermanentFailure, StatusTemporaryFailure,
+			StatusTechnicalFailure, StatusReceived, StatusCancelled, StatusVirusScanFailed, StatusValidationFailed} {
+			Expect(s.IsFinal()).To(BeTrue(), string(s))
+		}
+
+		for _, s := range []Status{StatusCreated, StatusSending, StatusPending, StatusAccepted, StatusPendingVirusCheck} {
+			Expect(s.IsFinal()).To(BeFalse(), string(s))
+		}
+	})
+
+	It("should tell the failures with IsFailure()", func() {
+		for _, s := range []Status{StatusFailed, StatusPermanentFailure, StatusTemporaryFailure, StatusTechnicalFailure,
+			StatusVirusScanFailed, StatusValidationFailed} {
+			Expect(s.IsFailure()).To(BeTrue(), string(s))
+		}
+
+		for _, s := range []Status{StatusDelivered, StatusSent, StatusReceived, StatusCancelled, StatusSending} {
+			Expect(s.IsFailure()).To(BeFalse(), string(s))
+		}
+	})
+
+	It("should tell the known statuses with IsValid()", func() {
+		Expect(StatusPendingVirusCheck.IsValid()).To(BeTrue())
+		Expect(Status("delivred").IsValid()).To(BeFalse())
+	})
+
+	It("should tell the known template types with IsValid()", func() {
+		Expect(TemplateTypeLetter.IsValid()).To(BeTrue())
+		Expect(TemplateType("Apple").IsValid()).To(BeFalse())
+	})
+})
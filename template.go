@@ -0,0 +1,39 @@
+package notify
+
+import "time"
+
+// TemplateDetail is the full description of a template as returned by
+// GOV.UK Notify.
+type TemplateDetail struct {
+	ID                 string     `json:"id"`
+	Name               string     `json:"name"`
+	Type               string     `json:"type"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          *time.Time `json:"updated_at"`
+	CreatedBy          string     `json:"created_by"`
+	Version            int64      `json:"version"`
+	Body               string     `json:"body"`
+	Subject            string     `json:"subject"`
+	LetterContactBlock string     `json:"letter_contact_block"`
+}
+
+// TemplateList is the response from GOV.UK Notify containing all the templates
+// of the current Service ID.
+type TemplateList struct {
+	Templates []TemplateDetail `json:"templates"`
+}
+
+// TemplatePreview is the response from GOV.UK Notify containing a template
+// rendered with the personalisation passed to the preview request.
+type TemplatePreview struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Version int64  `json:"version"`
+	Body    string `json:"body"`
+	Subject string `json:"subject"`
+	HTML    string `json:"html"`
+}
+
+type templatePreviewPayload struct {
+	Personalisation templateData `json:"personalisation"`
+}
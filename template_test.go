@@ -0,0 +1,120 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	httpmock "gopkg.in/jarcoal/httpmock.v1"
+)
+
+var _ = Describe("Template", func() {
+	var (
+		client *Client
+	)
+
+	BeforeEach(func() {
+		httpmock.Activate()
+
+		u, _ := url.Parse("https://example.com")
+
+		config := Configuration{
+			APIKey: []byte(`#5K+･ｼミew{ｦ住ｳ(跼Tﾉ(ｩ┫ﾒP.ｿﾓ燾辻G�感ﾃwb="=.!r.Oﾀﾍ奎gﾐ｣`),
+			BaseURL:   u,
+			ServiceID: "test",
+		}
+
+		client, _ = New(config)
+	})
+
+	AfterEach(func() {
+		httpmock.DeactivateAndReset()
+	})
+
+	It("should allow to GetTemplate() by ID", func() {
+		httpmock.RegisterResponder("GET", "https://example.com/v2/template/f33517ff-2a88-4f6e-b855-c550268ce08a",
+			httpmock.NewStringResponder(http.StatusOK, `{"id":"f33517ff-2a88-4f6e-b855-c550268ce08a","name":"Reminder","type":"email","created_at":"2017-04-20T10:00:00.000000Z","updated_at":null,"created_by":"someone@example.com","version":3,"body":"Hello ((name))","subject":"Reminder","letter_contact_block":null}`))
+
+		template, err := client.GetTemplate("f33517ff-2a88-4f6e-b855-c550268ce08a")
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(template.ID).To(Equal("f33517ff-2a88-4f6e-b855-c550268ce08a"))
+		Expect(template.Type).To(Equal("email"))
+		Expect(template.Version).To(Equal(int64(3)))
+		Expect(template.Body).To(Equal("Hello ((name))"))
+		Expect(template.UpdatedAt).To(BeNil())
+		Expect(template.CreatedAt.IsZero()).To(BeFalse())
+	})
+
+	It("should fallout if the GetTemplate() fails with not found", func() {
+		httpmock.RegisterResponder("GET", "https://example.com/v2/template/f33517ff-2a88-4f6e-b855-c550268ce08a",
+			httpmock.NewStringResponder(http.StatusNotFound, `{"status_code":404,"errors":[{"error": "NoResultFound","message": "No result found"}]}`))
+
+		template, err := client.GetTemplate("f33517ff-2a88-4f6e-b855-c550268ce08a")
+
+		Expect(err).Should(HaveOccurred())
+		Expect(template).To(BeNil())
+	})
+
+	It("should allow to GetTemplateVersion() by ID and version", func() {
+		httpmock.RegisterResponder("GET", "https://example.com/v2/template/f33517ff-2a88-4f6e-b855-c550268ce08a/version/2",
+			httpmock.NewStringResponder(http.StatusOK, `{"id":"f33517ff-2a88-4f6e-b855-c550268ce08a","type":"sms","version":2}`))
+
+		template, err := client.GetTemplateVersion("f33517ff-2a88-4f6e-b855-c550268ce08a", 2)
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(template.Version).To(Equal(int64(2)))
+	})
+
+	It("should allow to ListTemplates() of all types", func() {
+		httpmock.RegisterResponder("GET", "https://example.com/v2/templates",
+			httpmock.NewStringResponder(http.StatusOK, `{"templates":[{"id":"f33517ff-2a88-4f6e-b855-c550268ce08a","type":"email"},{"id":"0ba7a4bf-1e1c-4e2c-8fbb-63a6a7b0c0a4","type":"sms"}]}`))
+
+		list, err := client.ListTemplates("")
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(list.Templates).To(HaveLen(2))
+		Expect(list.Templates[1].Type).To(Equal("sms"))
+	})
+
+	It("should allow to ListTemplates() of a single type", func() {
+		httpmock.RegisterResponder("GET", "https://example.com/v2/templates?type=letter",
+			httpmock.NewStringResponder(http.StatusOK, `{"templates":[{"id":"f33517ff-2a88-4f6e-b855-c550268ce08a","type":"letter"}]}`))
+
+		list, err := client.ListTemplates("letter")
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(list.Templates).To(HaveLen(1))
+		Expect(list.Templates[0].Type).To(Equal("letter"))
+	})
+
+	It("should allow to PreviewTemplate() with personalisation", func() {
+		var body map[string]map[string]string
+
+		httpmock.RegisterResponder("POST", "https://example.com/v2/template/f33517ff-2a88-4f6e-b855-c550268ce08a/preview",
+			func(req *http.Request) (*http.Response, error) {
+				json.NewDecoder(req.Body).Decode(&body)
+
+				return httpmock.NewStringResponse(http.StatusOK, `{"id":"f33517ff-2a88-4f6e-b855-c550268ce08a","type":"email","version":3,"body":"Hello Betty","subject":"Reminder","html":"<p>Hello Betty</p>"}`), nil
+			})
+
+		preview, err := client.PreviewTemplate("f33517ff-2a88-4f6e-b855-c550268ce08a", templateData{"name": "Betty"})
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(body["personalisation"]["name"]).To(Equal("Betty"))
+		Expect(preview.Body).To(Equal("Hello Betty"))
+		Expect(preview.HTML).To(Equal("<p>Hello Betty</p>"))
+	})
+
+	It("should fallout if the PreviewTemplate() is missing personalisation", func() {
+		httpmock.RegisterResponder("POST", "https://example.com/v2/template/f33517ff-2a88-4f6e-b855-c550268ce08a/preview",
+			httpmock.NewStringResponder(http.StatusBadRequest, `{"status_code":400,"errors":[{"error": "BadRequestError","message": "Missing personalisation: name"}]}`))
+
+		preview, err := client.PreviewTemplate("f33517ff-2a88-4f6e-b855-c550268ce08a", templateData{})
+
+		Expect(err).Should(HaveOccurred())
+		Expect(preview).To(BeNil())
+	})
+})
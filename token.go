@@ -0,0 +1,48 @@
+package notify
+
+import (
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+// TokenSource provides the bearer token sent with every request to GOV.UK
+// Notify. Token is called once per request and must be safe for concurrent use.
+type TokenSource interface {
+	Token() (string, error)
+}
+
+// ClaimsProvider returns the claims of a new token. It is called every time a
+// token is minted, so time-based claims should be computed on every call.
+type ClaimsProvider func() jwt.Claims
+
+// StandardClaims is the ClaimsProvider expected by GOV.UK Notify, issuing the
+// token for the passed Service ID at the current time.
+func StandardClaims(serviceID string) ClaimsProvider {
+	return func() jwt.Claims {
+		return &jwt.StandardClaims{
+			Issuer:   serviceID,
+			IssuedAt: time.Now().Unix(),
+		}
+	}
+}
+
+// NewTokenSource returns a TokenSource minting a new token, signed with the
+// secret using HMAC-SHA256, for every request.
+func NewTokenSource(secret []byte, claims ClaimsProvider) TokenSource {
+	return &jwtTokenSource{
+		claims: claims,
+		secret: append([]byte(nil), secret...),
+	}
+}
+
+type jwtTokenSource struct {
+	claims ClaimsProvider
+	secret []byte
+}
+
+func (s *jwtTokenSource) Token() (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, s.claims())
+
+	return token.SignedString(s.secret)
+}
@@ -0,0 +1,88 @@
+package notify
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type stubTokenSource struct {
+	token string
+	err   error
+}
+
+func (s *stubTokenSource) Token() (string, error) {
+	return s.token, s.err
+}
+
+var _ = Describe("TokenSource", func() {
+	var secret = []byte("3d844edf-8d35-48ac-975b-e847b4f122b0")
+
+	parse := func(token string) *jwt.StandardClaims {
+		claims := jwt.StandardClaims{}
+		_, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+			return secret, nil
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		return &claims
+	}
+
+	It("should sign a Token() with the StandardClaims()", func() {
+		token, err := NewTokenSource(secret, StandardClaims("test")).Token()
+
+		Expect(err).ShouldNot(HaveOccurred())
+
+		claims := parse(token)
+		Expect(claims.Issuer).To(Equal("test"))
+		Expect(claims.IssuedAt).To(BeNumerically("~", time.Now().Unix(), 1))
+	})
+
+	It("should mint a new Token() on every call", func() {
+		issuedAt := time.Now().Add(-time.Hour)
+		source := NewTokenSource(secret, func() jwt.Claims {
+			issuedAt = issuedAt.Add(time.Minute)
+
+			return &jwt.StandardClaims{Issuer: "test", IssuedAt: issuedAt.Unix()}
+		})
+
+		first, _ := source.Token()
+		second, _ := source.Token()
+
+		Expect(parse(second).IssuedAt - parse(first).IssuedAt).To(Equal(int64(60)))
+	})
+
+	It("should not freeze the claims in the Configuration on Authenticate()", func() {
+		config := Configuration{APIKey: secret, ServiceID: "test"}
+
+		first, _ := config.Authenticate(config.APIKey)
+		Expect(config).To(Equal(Configuration{APIKey: secret, ServiceID: "test"}))
+
+		second, _ := config.Authenticate(config.APIKey)
+		Expect(parse(*second).Issuer).To(Equal(parse(*first).Issuer))
+	})
+
+	It("should use the TokenSource of the Configuration to buildHeaders()", func() {
+		client, _ := New(Configuration{TokenSource: &stubTokenSource{token: "t0k3n"}})
+
+		req, _ := http.NewRequest("GET", "https://example.com", nil)
+		err := client.buildHeaders(req)
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(req.Header.Get("Authorization")).To(Equal("Bearer t0k3n"))
+	})
+
+	It("should fail to buildHeaders() when the TokenSource fails", func() {
+		client, _ := New(Configuration{TokenSource: &stubTokenSource{err: errors.New("no token")}})
+
+		req, _ := http.NewRequest("GET", "https://example.com", nil)
+		err := client.buildHeaders(req)
+
+		Expect(err).Should(HaveOccurred())
+	})
+})
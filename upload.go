@@ -0,0 +1,63 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+)
+
+// MaxUploadSize is the largest file, in bytes, that can be sent by email.
+const MaxUploadSize = 2 * 1024 * 1024
+
+// ErrUploadTooLarge is returned by PrepareUpload when the file is larger than
+// MaxUploadSize.
+var ErrUploadTooLarge = errors.New("upload: file is larger than 2MB")
+
+// UploadOptions for a file sent by email. All options are optional and Notify's
+// defaults are used for the ones not set.
+type UploadOptions struct {
+	// Filename shown to the recipient, including the file extension.
+	Filename string
+	// ConfirmEmailBeforeDownload makes the recipient enter their email address
+	// before downloading the file. Notify defaults to true when nil.
+	ConfirmEmailBeforeDownload *bool
+	// RetentionPeriod the file is available for, such as "52 weeks". Notify
+	// defaults to "26 weeks" when empty.
+	RetentionPeriod string
+}
+
+// Upload is a file to be sent by email. It should be passed as the value of
+// the placeholder in the personalisation where the link to download the file
+// is to appear.
+type Upload struct {
+	File                       string `json:"file"`
+	Filename                   string `json:"filename,omitempty"`
+	ConfirmEmailBeforeDownload *bool  `json:"confirm_email_before_download,omitempty"`
+	RetentionPeriod            string `json:"retention_period,omitempty"`
+}
+
+// PrepareUpload reads the file from r and prepares it to be sent by email as
+// part of the personalisation. ErrUploadTooLarge is returned when the file is
+// larger than MaxUploadSize.
+func PrepareUpload(r io.Reader, options UploadOptions) (*Upload, error) {
+	var buf bytes.Buffer
+
+	n, err := io.Copy(&buf, io.LimitReader(r, MaxUploadSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if n > MaxUploadSize {
+		return nil, ErrUploadTooLarge
+	}
+
+	u := Upload{
+		File:                       base64.StdEncoding.EncodeToString(buf.Bytes()),
+		Filename:                   options.Filename,
+		ConfirmEmailBeforeDownload: options.ConfirmEmailBeforeDownload,
+		RetentionPeriod:            options.RetentionPeriod,
+	}
+
+	return &u, nil
+}
@@ -0,0 +1,87 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	httpmock "gopkg.in/jarcoal/httpmock.v1"
+)
+
+var _ = Describe("Upload", func() {
+	It("should PrepareUpload() with the default options", func() {
+		u, err := PrepareUpload(bytes.NewReader([]byte("%PDF-1.4")), UploadOptions{})
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(u.File).To(Equal("JVBERi0xLjQ="))
+
+		b, _ := json.Marshal(u)
+		Expect(string(b)).To(Equal(`{"file":"JVBERi0xLjQ="}`))
+	})
+
+	It("should PrepareUpload() with all the options", func() {
+		confirm := false
+
+		u, err := PrepareUpload(bytes.NewReader([]byte("a,b\n")), UploadOptions{
+			Filename:                   "report.csv",
+			ConfirmEmailBeforeDownload: &confirm,
+			RetentionPeriod:            "52 weeks",
+		})
+
+		Expect(err).ShouldNot(HaveOccurred())
+
+		b, _ := json.Marshal(u)
+		Expect(string(b)).To(Equal(`{"file":"YSxiCg==","filename":"report.csv","confirm_email_before_download":false,"retention_period":"52 weeks"}`))
+	})
+
+	It("should allow to PrepareUpload() a file of exactly MaxUploadSize", func() {
+		_, err := PrepareUpload(bytes.NewReader(make([]byte, MaxUploadSize)), UploadOptions{})
+
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	It("should fail to PrepareUpload() a file larger than MaxUploadSize", func() {
+		u, err := PrepareUpload(bytes.NewReader(make([]byte, MaxUploadSize+1)), UploadOptions{})
+
+		Expect(err).To(Equal(ErrUploadTooLarge))
+		Expect(u).To(BeNil())
+	})
+
+	It("should send the upload as part of the SendEmail() personalisation", func() {
+		var body map[string]map[string]interface{}
+
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		u, _ := url.Parse("https://example.com")
+		client, _ := New(Configuration{
+			APIKey:    []byte("secret"),
+			BaseURL:   u,
+			ServiceID: "test",
+		})
+
+		httpmock.RegisterResponder("POST", "https://example.com/v2/notifications/email",
+			func(req *http.Request) (*http.Response, error) {
+				json.NewDecoder(req.Body).Decode(&body)
+
+				return httpmock.NewStringResponse(http.StatusCreated, `{"id":"df10a23e-2c6d-4ea5-87fb-82e520cbf93a"}`), nil
+			})
+
+		upload, _ := PrepareUpload(bytes.NewReader([]byte("%PDF-1.4")), UploadOptions{Filename: "decision.pdf"})
+
+		_, err := client.SendEmail("test@example.com", "123456qwerty", templateData{
+			"name":         "Betty",
+			"link_to_file": upload,
+		}, "")
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(body["personalisation"]["name"]).To(Equal("Betty"))
+		Expect(body["personalisation"]["link_to_file"]).To(Equal(map[string]interface{}{
+			"file":     "JVBERi0xLjQ=",
+			"filename": "decision.pdf",
+		}))
+	})
+})